@@ -0,0 +1,151 @@
+package logtimer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// AsciicastHeader is the header of an asciicast v2 recording, see
+// https://docs.asciinema.org/manual/asciicast/v2/.
+type AsciicastHeader struct {
+	// Width and Height are the size of the terminal in columns and rows.
+	Width  int
+	Height int
+	// Timestamp is the time the recording started.
+	Timestamp time.Time
+	// Command is the command that was recorded, it is omitted if it is empty.
+	Command string
+	// Env holds environment variables of the recording, e.g. SHELL and TERM, it is omitted if it is empty.
+	Env map[string]string
+}
+
+// AppendJSON appends the header and a line feed to dst.
+func (h AsciicastHeader) AppendJSON(dst []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	enc := json.NewEncoder(buf)
+	// keep the command readable, e.g. 2>&1
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(struct {
+		Version   int               `json:"version"`
+		Width     int               `json:"width"`
+		Height    int               `json:"height"`
+		Timestamp int64             `json:"timestamp,omitempty"`
+		Command   string            `json:"command,omitempty"`
+		Env       map[string]string `json:"env,omitempty"`
+	}{
+		Version:   2,
+		Width:     h.Width,
+		Height:    h.Height,
+		Timestamp: timestamp(h.Timestamp),
+		Command:   h.Command,
+		Env:       h.Env,
+	})
+	if err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+func timestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// AsciicastReader reads chunks from Reader and returns them as asciicast v2 output events:
+//
+//	[1.500000, "o", "Hello World\r\n"]
+//
+// The time of an event is the time elapsed since the start of Timer when the chunk arrived. A chunk that ends with
+// an incomplete UTF-8 sequence is held back until the sequence is complete.
+type AsciicastReader struct {
+	// Timer stamps the events, if it is nil a Timer that starts with the first Read is used.
+	// Share a Timer between readers to record multiple streams, e.g. the stdout and stderr of a command, the events
+	// are stamped when they are read, see AsciicastWriter to stamp them in the order they are written.
+	Timer *Timer
+	// Clock is used for the arrival time of the chunks, if it is nil SystemClock is used.
+	Clock Clock
+	// CRLF records line feeds as \r\n like a terminal does, set it if Reader is not a terminal, e.g. a pipe.
+	CRLF bool
+	io.Reader
+
+	events eventWriter
+	buffer readBuffer
+}
+
+func (ar *AsciicastReader) Read(p []byte) (int, error) {
+	if ar.Timer == nil {
+		ar.Timer = NewTimerWithClock(ar.Clock)
+	}
+	return ar.buffer.read(p, ar.Reader, ar)
+}
+
+func (ar *AsciicastReader) process(data []byte, err error) {
+	if len(data) > 0 {
+		ar.events.append(data, ar.CRLF)
+		ar.events.write(&ar.buffer.Buffer, ar.Timer, clockOrDefault(ar.Clock).Now(), err != nil)
+	} else if err != nil && len(ar.events.pending) > 0 {
+		ar.events.write(&ar.buffer.Buffer, ar.Timer, clockOrDefault(ar.Clock).Now(), true)
+	}
+}
+
+// eventWriter holds the pending output of AsciicastReader and AsciicastWriter.
+type eventWriter struct {
+	// pending holds the start of an incomplete UTF-8 sequence
+	pending []byte
+	// last is the last byte that was appended
+	last byte
+}
+
+// append adds p to the pending data, with crlf line feeds are recorded as \r\n.
+func (ew *eventWriter) append(p []byte, crlf bool) {
+	if !crlf {
+		ew.pending = append(ew.pending, p...)
+		return
+	}
+	for _, b := range p {
+		if b == '\n' && ew.last != '\r' {
+			ew.pending = append(ew.pending, '\r')
+		}
+		ew.pending = append(ew.pending, b)
+		ew.last = b
+	}
+}
+
+// write writes the pending data that is complete as an output event to buf, with final all of it is written.
+func (ew *eventWriter) write(buf *bytes.Buffer, timer *Timer, arrival time.Time, final bool) {
+	data := ew.pending
+	if !final {
+		data = data[:completeLength(data)]
+	}
+	if len(data) == 0 {
+		return
+	}
+	dst := buf.AvailableBuffer()
+	dst = append(dst, '[')
+	dst = strconv.AppendFloat(dst, timer.NextAt(arrival).Elapsed().Seconds(), 'f', 6, 64)
+	dst = append(dst, `, "o", `...)
+	dst = appendJSONString(dst, data)
+	dst = append(dst, "]\n"...)
+	_, _ = buf.Write(dst)
+	ew.pending = append(ew.pending[:0], ew.pending[len(data):]...)
+}
+
+// completeLength returns the length of p without an incomplete UTF-8 sequence at its end.
+func completeLength(p []byte) int {
+	// a UTF-8 sequence is at most utf8.UTFMax bytes long
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(p)
+}
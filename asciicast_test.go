@@ -0,0 +1,123 @@
+package logtimer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsciicastHeader(t *testing.T) {
+	h := AsciicastHeader{
+		Width:     80,
+		Height:    24,
+		Timestamp: time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC),
+		Command:   "make build 2>&1",
+		Env:       map[string]string{"TERM": "xterm-256color", "SHELL": "/bin/bash"},
+	}
+	b, err := h.AppendJSON(nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"version":2,"width":80,"height":24,"timestamp":1549538805,"command":"make build 2>&1",`+
+		`"env":{"SHELL":"/bin/bash","TERM":"xterm-256color"}}`+"\n", string(b))
+
+	b, err = AsciicastHeader{Width: 120, Height: 40}.AppendJSON(nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"version":2,"width":120,"height":40}`+"\n", string(b))
+}
+
+func TestAsciicastReader(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+
+	t.Run("Events", func(t *testing.T) {
+		in := make(chan string)
+		reader := &AsciicastReader{
+			Reader: &chanReader{c: in},
+			Clock:  NewFakeClock(start, 1500*time.Millisecond),
+		}
+		go func() {
+			in <- "Hello\r\n"
+			in <- "\x1b[31m\"red\"\x1b[0m"
+			in <- "\xe6\x97"
+			in <- "\xa5\n"
+			close(in)
+		}()
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		// an incomplete UTF-8 sequence is written with the chunk that completes it
+		require.Equal(t, `[1.500000, "o", "Hello\r\n"]`+"\n"+
+			`[3.000000, "o", "\u001b[31m\"red\"\u001b[0m"]`+"\n"+
+			`[6.000000, "o", "日\n"]`+"\n", string(out))
+	})
+
+	t.Run("CRLF", func(t *testing.T) {
+		for _, size := range []int{1, 64} {
+			reader := &AsciicastReader{
+				Reader: &chunkReader{data: []byte("a\nb\r\n\n"), size: size},
+				Clock:  NewFakeClock(start, 0),
+				CRLF:   true,
+			}
+			out, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			require.Equal(t, "a\r\nb\r\n\r\n", string(decodeEvents(t, out)), "size %d", size)
+		}
+	})
+
+	t.Run("Data with Error", func(t *testing.T) {
+		errRead := errors.New("read failed")
+		reader := &AsciicastReader{
+			Reader: &errReader{data: []byte("Hello\xe6"), err: errRead},
+			Clock:  NewFakeClock(start, 0),
+		}
+		out, err := io.ReadAll(reader)
+		require.ErrorIs(t, err, errRead)
+		// the data that arrived with the error is written, even the incomplete UTF-8 sequence
+		require.Equal(t, `[0.000000, "o", "Hello�"]`+"\n", string(out))
+	})
+
+	t.Run("Incomplete UTF-8 at EOF", func(t *testing.T) {
+		reader := &AsciicastReader{
+			Reader: &chunkReader{data: []byte("a\xe6\x97"), size: 64},
+			Clock:  NewFakeClock(start, 0),
+		}
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, `[0.000000, "o", "a"]`+"\n"+`[0.000000, "o", "��"]`+"\n", string(out))
+	})
+}
+
+func TestCompleteLength(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"日", 3},
+		{"a\xe6", 1},
+		{"a\xe6\x97", 1},
+		{"\xf0\x9f\x9a", 0},
+		{"\xf0\x9f\x9a\x80", 4},
+		{"\xff", 1},
+		{"a\x97", 2},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, completeLength([]byte(test.input)), "%q", test.input)
+	}
+}
+
+// decodeEvents returns the data of the asciicast events in b.
+func decodeEvents(t *testing.T, b []byte) []byte {
+	var data []byte
+	for _, line := range bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n")) {
+		var event []any
+		require.NoError(t, json.Unmarshal(line, &event))
+		require.Len(t, event, 3)
+		require.Equal(t, "o", event[1])
+		data = append(data, event[2].(string)...)
+	}
+	return data
+}
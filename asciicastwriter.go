@@ -0,0 +1,59 @@
+package logtimer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// AsciicastWriter is the io.Writer counterpart of AsciicastReader, it writes the chunks that are written to it as
+// asciicast v2 output events to Writer.
+//
+// Every Write results in at most one event, which is stamped when it is written. Writers that share a Timer keep
+// their events in order if their writes are serialized, e.g. for the stdout and stderr of a command.
+type AsciicastWriter struct {
+	// Timer stamps the events, if it is nil a Timer that starts with the first Write is used.
+	Timer *Timer
+	// Clock is used for the time of the events, if it is nil SystemClock is used.
+	Clock Clock
+	// CRLF records line feeds as \r\n like a terminal does, set it if the output does not come from a terminal.
+	CRLF bool
+	io.Writer
+
+	mu     sync.Mutex
+	events eventWriter
+	buffer bytes.Buffer
+}
+
+func (w *AsciicastWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Timer == nil {
+		w.Timer = NewTimerWithClock(w.Clock)
+	}
+	if len(p) > 0 {
+		w.events.append(p, w.CRLF)
+		w.events.write(&w.buffer, w.Timer, clockOrDefault(w.Clock).Now(), false)
+	}
+	return len(p), w.writeBuffer()
+}
+
+// Close writes a pending incomplete UTF-8 sequence as an event, it does not close Writer.
+func (w *AsciicastWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.events.pending) > 0 {
+		w.events.write(&w.buffer, w.Timer, clockOrDefault(w.Clock).Now(), true)
+	}
+	return w.writeBuffer()
+}
+
+// writeBuffer writes the events to Writer, the events that were not written are kept.
+func (w *AsciicastWriter) writeBuffer() error {
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	n, err := w.Writer.Write(w.buffer.Bytes())
+	w.buffer.Next(n)
+	return err
+}
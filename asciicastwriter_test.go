@@ -0,0 +1,63 @@
+package logtimer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsciicastWriter(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+
+	t.Run("Events", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &AsciicastWriter{
+			Writer: &out,
+			Clock:  NewFakeClock(start, 1500*time.Millisecond),
+			CRLF:   true,
+		}
+		for _, s := range []string{"Hello\n", "\xe6\x97", "\xa5\n", "a\xe6"} {
+			n, err := writer.Write([]byte(s))
+			require.NoError(t, err)
+			require.Equal(t, len(s), n)
+		}
+		// an incomplete UTF-8 sequence is written with the chunk that completes it, or by Close
+		require.Equal(t, `[1.500000, "o", "Hello\r\n"]`+"\n"+
+			`[4.500000, "o", "日\r\n"]`+"\n"+
+			`[6.000000, "o", "a"]`+"\n", out.String())
+		require.NoError(t, writer.Close())
+		require.Equal(t, `[1.500000, "o", "Hello\r\n"]`+"\n"+
+			`[4.500000, "o", "日\r\n"]`+"\n"+
+			`[6.000000, "o", "a"]`+"\n"+
+			`[7.500000, "o", "�"]`+"\n", out.String())
+	})
+
+	t.Run("Write Error", func(t *testing.T) {
+		out := &failingWriter{fail: true}
+		writer := &AsciicastWriter{Writer: out, Clock: NewFakeClock(start, 0)}
+
+		n, err := writer.Write([]byte("Hello"))
+		require.ErrorIs(t, err, errWrite)
+		require.Equal(t, 5, n)
+
+		// the event that was not written is kept
+		out.fail = false
+		_, err = writer.Write([]byte(" World"))
+		require.NoError(t, err)
+		require.Equal(t, `[0.000000, "o", "Hello"]`+"\n"+`[0.000000, "o", " World"]`+"\n", out.String())
+	})
+
+	t.Run("Shared Timer", func(t *testing.T) {
+		clock := NewFakeClock(start, time.Second)
+		timer := NewTimerWithClock(clock)
+		var out bytes.Buffer
+		stdout := &AsciicastWriter{Writer: &out, Timer: timer, Clock: clock}
+		stderr := &AsciicastWriter{Writer: &out, Timer: timer, Clock: clock}
+		for _, w := range []*AsciicastWriter{stdout, stderr, stdout} {
+			_, _ = w.Write([]byte("x"))
+		}
+		require.Equal(t, `[1.000000, "o", "x"]`+"\n"+`[2.000000, "o", "x"]`+"\n"+`[3.000000, "o", "x"]`+"\n", out.String())
+	})
+}
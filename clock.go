@@ -0,0 +1,68 @@
+package logtimer
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of the current time of Timer, PrefixReader and PrefixWriter.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock that returns the time of the system, it is used if no Clock is set.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockOrDefault returns c, or SystemClock if it is nil.
+func clockOrDefault(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
+
+// FakeClock is a deterministic Clock for tests. Every call to Now returns the current time of the clock and then
+// advances it by the step, so consecutive calls return distinct times without waiting.
+// It is safe to use a FakeClock from multiple goroutines.
+type FakeClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock returns a FakeClock that starts at start and advances by step on every call to Now.
+func NewFakeClock(start time.Time, step time.Duration) *FakeClock {
+	return &FakeClock{
+		now:  start,
+		step: step,
+	}
+}
+
+// Now returns the current time of the clock and advances it by the step.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Set sets the current time of the clock.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance advances the clock by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
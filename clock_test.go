@@ -0,0 +1,58 @@
+package logtimer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+	clock := NewFakeClock(start, time.Second)
+
+	require.Equal(t, start, clock.Now())
+	require.Equal(t, start.Add(time.Second), clock.Now())
+
+	clock.Advance(time.Minute)
+	require.Equal(t, start.Add(time.Minute+2*time.Second), clock.Now())
+
+	clock.Set(start)
+	require.Equal(t, start, clock.Now())
+}
+
+func TestNewTimerWithClock(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+	timer := NewTimerWithClock(NewFakeClock(start, 1500*time.Millisecond))
+
+	require.Equal(t, start, timer.Start())
+	first := timer.Next()
+	require.Equal(t, start, first.Start)
+	require.Equal(t, start.Add(1500*time.Millisecond), first.Time)
+
+	second := timer.Next()
+	require.Equal(t, 1500*time.Millisecond, second.Delta())
+	require.Equal(t, 3*time.Second, second.Elapsed())
+
+	require.Equal(t, "[00:00:04] ", timer.FormatFunc("[%X] ", ElapsedNamespace)())
+}
+
+func TestPrefixWriterClock(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+	clock := NewFakeClock(start, time.Second)
+	timer := NewTimerWithClock(clock)
+	var out bytes.Buffer
+	w := &PrefixWriter{
+		Writer:   &out,
+		FormatAt: (&Compiler{DefaultNamespace: ElapsedNamespace}).mustCompile("[%X] ").FormatAtFunc(timer),
+		Clock:    clock,
+	}
+	_, err := w.Write([]byte("Hello\nWor"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("ld\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("!\n"))
+	require.NoError(t, err)
+	require.Equal(t, "[00:00:01] Hello\n[00:00:01] World\n[00:00:03] !\n", out.String())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+)
+
+// runCommand starts the command described by args, passes its stdout and stderr through the writers created by
+// stdoutWriter and stderrWriter, which write to the writer returned by open, and returns the exit code of the
+// command. Only one of the writers is written to at a time, so lines are stamped in the order they are written.
+// open is called once the command has started, so nothing is created for a command that cannot be run, if it fails
+// the command is killed. Signals received by logtimer are forwarded to the command.
+func runCommand(open func() (io.Writer, error), args []string, stdoutWriter, stderrWriter func(io.Writer) io.WriteCloser) (int, error) {
+	cmd := exec.Command(args[0], args[1:]...) //nolint: gosec // running the user supplied command is the purpose
+	cmd.Stdin = os.Stdin
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	w, err := open()
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return 0, err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		copyStream(&mu, stdoutWriter(w), stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		copyStream(&mu, stderrWriter(w), stderr)
+	}()
+	// all output must be consumed before calling Wait, it closes the pipes
+	wg.Wait()
+
+	err = cmd.Wait()
+	signal.Stop(signals)
+	close(signals)
+
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitCode(exitErr), nil
+	}
+	return 0, err
+}
+
+// copyStream copies r to w and closes w at the end of r, w is only written to while holding mu.
+func copyStream(mu *sync.Mutex, w io.WriteCloser, r io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		mu.Lock()
+		if n > 0 {
+			_, _ = w.Write(buf[:n])
+		}
+		if err != nil {
+			_ = w.Close()
+		}
+		mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecExitCode(t *testing.T) {
+	out, code := runMain(t, "", "--", "sh", "-c", "exit 3")
+	require.Equal(t, 3, code)
+	require.Empty(t, out)
+}
+
+func TestExecSignal(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		output   string
+		expected int
+	}{
+		// the command handles the forwarded signal itself
+		{"Trapped", `trap 'echo term; exit 7' TERM; echo ready; while :; do sleep 0.01; done`, "term\n", 7},
+		// the command is terminated by the forwarded signal, logtimer exits with 128 + SIGTERM like a shell
+		{"Terminated", `echo ready; exec sleep 10`, "", 143},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			cmd := mainCommand("--stdout-format=", "--", "sh", "-c", test.script)
+			stdout, err := cmd.StdoutPipe()
+			require.NoError(t, err)
+			require.NoError(t, cmd.Start())
+
+			// logtimer forwards signals once the output of the command is copied
+			br := bufio.NewReader(stdout)
+			line, err := br.ReadString('\n')
+			require.NoError(t, err)
+			require.True(t, strings.HasSuffix(line, "ready\n"), line)
+			require.NoError(t, cmd.Process.Signal(syscall.SIGTERM))
+
+			rest, err := io.ReadAll(br)
+			require.NoError(t, err)
+			err = cmd.Wait()
+			var exitErr *exec.ExitError
+			require.True(t, errors.As(err, &exitErr), "%v", err)
+			require.Equal(t, test.expected, exitErr.ExitCode())
+			if test.output == "" {
+				require.Empty(t, rest)
+			} else {
+				require.True(t, strings.HasSuffix(string(rest), test.output), string(rest))
+			}
+		})
+	}
+}
+
+func TestExecMerge(t *testing.T) {
+	out, code := runMain(t, "", "--stdout-format=O ", "--stderr-format=E ", "--",
+		"sh", "-c", "echo a; echo b >&2; echo c; echo d >&2; echo e")
+	require.Equal(t, 0, code)
+	// the streams are read concurrently, only the order within a stream is known
+	var stdout, stderr []string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "O "):
+			stdout = append(stdout, line)
+		case strings.HasPrefix(line, "E "):
+			stderr = append(stderr, line)
+		default:
+			require.Fail(t, "unexpected line", "%q", line)
+		}
+	}
+	require.Equal(t, []string{"O a", "O c", "O e"}, stdout)
+	require.Equal(t, []string{"E b", "E d"}, stderr)
+}
+
+func TestExecRecordOrder(t *testing.T) {
+	out, code := runMain(t, "", "--output=json", "--",
+		"sh", "-c", "i=0; while [ $i -lt 5000 ]; do echo o; echo e >&2; i=$((i+1)); done")
+	require.Equal(t, 0, code)
+	// the streams are read concurrently, but the records are stamped in the order they are written
+	var previous struct {
+		Elapsed int64 `json:"elapsed_ns"`
+		Delta   int64 `json:"delta_ns"`
+		Seq     int64 `json:"seq"`
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 10000)
+	for _, line := range lines {
+		record := previous
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		require.Equal(t, previous.Seq+1, record.Seq, line)
+		require.GreaterOrEqual(t, record.Elapsed, previous.Elapsed, line)
+		require.GreaterOrEqual(t, record.Delta, int64(0), line)
+		previous = record
+	}
+}
+
+func TestExecEncodedRecords(t *testing.T) {
+	// the command waits for stdin, so the record must be written while it is still running
+	cmd := mainCommand("--output=json", "--delimiter=nul", "--", "sh", "-c", `printf 'a\0'; read -r line`)
+	stdin, err := cmd.StdinPipe()
+	require.NoError(t, err)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = stdin.Close()
+		_ = cmd.Wait()
+	}()
+
+	lines := make(chan string)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		require.Contains(t, line, `"line":"a"`)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the record was not written before the command exited")
+	}
+}
@@ -0,0 +1,65 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Eun/logtimer"
+)
+
+// openKeys switches the terminal to read single key presses without echoing them and returns the keys that are
+// pressed. restore switches the terminal back.
+func openKeys() (keys <-chan byte, restore func(), err error) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, nil, err
+	}
+	state, err := stty(tty, "-g")
+	if err != nil {
+		_ = tty.Close()
+		return nil, nil, err
+	}
+	// -isig delivers Ctrl+C as a key, so the terminal is restored before logtimer exits
+	if _, err := stty(tty, "-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		_ = tty.Close()
+		return nil, nil, err
+	}
+
+	c := make(chan byte)
+	// done stops the goroutine, closing tty ends a pending Read
+	done := make(chan struct{})
+	go func() {
+		defer close(c)
+		var b [1]byte
+		for {
+			if _, err := tty.Read(b[:]); err != nil {
+				return
+			}
+			if b[0] == 0x03 || b[0] == 0x04 {
+				// Ctrl+C and Ctrl+D quit
+				b[0] = logtimer.QuitKey
+			}
+			select {
+			case c <- b[0]:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return c, func() {
+		close(done)
+		_, _ = stty(tty, strings.TrimSpace(state))
+		_ = tty.Close()
+	}, nil
+}
+
+// stty runs stty with args for the terminal tty and returns its output.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
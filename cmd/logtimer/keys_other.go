@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package main
+
+import "errors"
+
+// openKeys is not supported, the replay cannot be controlled with the keyboard.
+func openKeys() (keys <-chan byte, restore func(), err error) {
+	return nil, nil, errors.New("keyboard controls are not supported")
+}
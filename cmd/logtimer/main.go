@@ -1,12 +1,12 @@
 package main
 
 import (
-	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+	_ "time/tzdata" // embed the time zone database, so --tz works on systems without it
 
 	"github.com/Eun/logtimer"
 	"github.com/spf13/cobra"
@@ -19,82 +19,107 @@ var (
 )
 
 func main() {
-	var relativeFlag string
-	var formatFlag string
-	var colorCorrection string
+	var opts options
+	var exitCode int
 	var rootCmd = &cobra.Command{
-		Use: filepath.Base(os.Args[0]),
-		Run: func(cmd *cobra.Command, args []string) {
-			var cc logtimer.ColorCorrection
-			switch strings.ToLower(colorCorrection) {
-			case "true", "normal", "standard", "enable":
-				cc = logtimer.Enabled
-			case "alternate":
-				cc = logtimer.Alternate
-			default:
-				cc = logtimer.Disabled
-			}
-
-			var format logtimer.FormatFunc
-
-			if relativeFlag != "" {
-				startTime := time.Now()
-				format = func() string {
-					return logtimer.FormatDuration(time.Since(startTime), relativeFlag)
-				}
-			} else {
-				format = func() string {
-					return logtimer.FormatTime(time.Now(), formatFlag)
-				}
-			}
-
-			reader := &logtimer.PrefixReader{
-				Reader:          os.Stdin,
-				Format:          format,
-				ColorCorrection: cc,
-			}
-
-			_, _ = io.Copy(os.Stdout, reader)
+		Use:  filepath.Base(os.Args[0]) + " [flags] [-- command [args...]]",
+		Args: cobra.ArbitraryArgs,
+		// errors are reported by log.Fatal below
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			opts.formatSet = cmd.Flags().Changed("format")
+			exitCode, err = opts.run(args)
+			return err
 		},
 	}
 	rootCmd.Version = version + " " + date + " " + commit
-	rootCmd.Flags().StringVarP(&formatFlag, "format", "f", "[%X] ", `format to prefix the lines. You can use following directives to format the date:
+	rootCmd.Flags().StringVarP(&opts.format, "format", "f", "[%X] ", `format to prefix the lines. You can use following directives to format the date:
 	%a    Weekday as locale’s abbreviated name.                             (Sun, Mon, ..., Sat)
 	%A    Weekday as locale’s full name.                                    (Sunday, Monday, ..., Saturday)
 	%w    Weekday as a decimal number, where 0 is Sunday and 6 is Saturday  (0, 1, ..., 6)
+	%u    Weekday as a decimal number, where 1 is Monday and 7 is Sunday    (1, 2, ..., 7)
 	%d    Day of the month as a decimal number.                             (1, 2, ..., 31)
+	%e    Day of the month as a space padded decimal number.                ( 1,  2, ..., 31)
 	%b    Month as locale’s abbreviated name.                               (Jan, Feb, ..., Dec)
+	%h    Same as %b.                                                       (Jan, Feb, ..., Dec)
 	%B    Month as locale’s full name.                                      (January, February, ..., December)
 	%m    Month as a decimal number.                                        (1, 2, ..., 12)
 	%y    Year without century as a decimal number.                         (0, 1, ..., 99)
 	%Y    Year with century as a decimal number.                            (1970, 1988, 2001, 2013)
+	%C    Century as a zero padded decimal number.                          (19, 20)
+	%G    ISO 8601 week-based year with century as a decimal number.        (1970, 1988, 2001, 2013)
+	%g    ISO 8601 week-based year without century as a zero padded decimal number. (00, 01, ..., 99)
 	%H    Hour (24-hour clock) as a decimal number.                          (0, 1, ..., 23)
+	%k    Hour (24-hour clock) as a space padded decimal number.            ( 0,  1, ..., 23)
 	%I    Hour (12-hour clock) as a decimal number.                          (1, 2, ..., 12)
+	%l    Hour (12-hour clock) as a space padded decimal number.            ( 1,  2, ..., 12)
 	%p    Meridian indicator.                                               (AM, PM)
+	%P    Meridian indicator in lower case.                                 (am, pm)
 	%M    Minute as a decimal number.                                       (0, 1, ..., 59)
 	%S    Second as a decimal number.                                       (0, 1, ..., 59)
 	%f    Microsecond as a decimal number.                                  (0, 1, ..., 999999)
+	%L    Millisecond as a zero padded decimal number.                      (000, 001, ..., 999)
+	%N    Nanosecond as a zero padded decimal number.                       (000000000, ..., 999999999)
+	%s    Seconds since the Unix epoch.                                     (1549538805)
 	%z    UTC offset in the form +HHMM or -HHMM                             (+0000)
+	%:z   UTC offset in the form +HH:MM or -HH:MM                           (+00:00)
 	%Z    Time zone name                                                    (UTC)
 	%j    Day of the year as a decimal number                               (1, 2, ..., 366)
 	%U    Week number of the year (Sunday as the first day of the week) as a decimal number. All days in a new year preceding the first Sunday are considered to be in week 0.
 	                                                                        (0, 1, ..., 53)
 	%W    Week number of the year (Monday as the first day of the week) as a decimal number. All days in a new year preceding the first Monday are considered to be in week 0.
 	                                                                        (0, 1, ..., 53)
+	%V    ISO 8601 week number of the year as a zero padded decimal number. (01, 02, ..., 53)
 	%c    Date and time representation.                                     (Tue Aug 16 21:30:00 1988)
 	%x    Date representation.                                              (08/16/88)
 	%X    Time representation.                                              (21:30:00)
+	%D    Same as %m/%d/%y with zero padding.                               (08/16/88)
+	%F    Same as %Y-%m-%d with zero padding.                               (1988-08-16)
+	%T    Same as %H:%M:%S with zero padding.                               (21:30:00)
+	%R    Same as %H:%M with zero padding.                                  (21:30)
+	%n    A newline character.
+	%t    A tab character.
 	%%    A literal '%' character.                                          (%)
 
 	It is possible to to zero/space pad the directives
     Example:
 		ping 8.8.8.8 | logtimer --format="[%a, %d %b %Y %02H:%02M:%02S %Z] "
 
+	The directives of --relative and --delta can be mixed in by qualifying them with their namespace
+	%{time:X}, %{elapsed:X}, %{delta:X}
+    Example:
+		ping 8.8.8.8 | logtimer --format="[%X +%{elapsed:Xf}] "
+
+	To print the time of another time zone qualify the directive with an IANA time zone
+	%{time@UTC:X}, %{time@America/New_York:X}
+    Example:
+		ping 8.8.8.8 | logtimer --format="[%X %Z / %{time@UTC:X} UTC] "
+
+	The prefix can be styled, styles are dropped if the output is not a terminal or NO_COLOR is set
+	%{fg:cyan}, %{bg:blue}, %{fg:208}, %{fg:#ff8000}   Set the text or background color (`+strings.Join(logtimer.ColorNames(), ", ")+`, 0-255 or #rrggbb)
+	%{bold}, %{dim}, %{italic}, %{underline}, %{blink}, %{reverse}, %{hidden}, %{strike}   Set an attribute
+	%{reset}   Reset the style
+    Example:
+		ping 8.8.8.8 | logtimer --format="%{fg:cyan}[%X]%{reset} "
+
 `)
-	rootCmd.Flags().StringVarP(&relativeFlag, "relative", "r", "", `use relative log mode, this means that the clock will start at execution date. You can use following directives to format the time
+	rootCmd.Flags().StringVarP(&opts.relative, "relative", "r", "", `use relative log mode, this means that the clock will start at execution date. You can use following directives to format the time
 	%X    Total Time elapsed.                                               (85:30:04)
 	%Xf   Total Time with Microseconds elapsed.                             (85:30:04.999999)
 	%Xn   Total Time with Nanoseconds elapsed.                              (85:30:04.999999999)
+	%D    Days elapsed.                                                     (0, 1, ..., 3)
+	%H    Hours of the day elapsed.                                         (0, 1, ..., 23)
+	%M    Minutes of the hour elapsed.                                      (0, 1, ..., 59)
+	%S    Seconds of the minute elapsed.                                    (0, 1, ..., 59)
+	%L    Milliseconds of the second elapsed as a zero padded number.       (000, 001, ..., 999)
+	%f    Microseconds of the second elapsed as a zero padded number.       (000000, 000001, ..., 999999)
+	%N    Nanoseconds of the second elapsed as a zero padded number.        (000000000, ..., 999999999)
+	%s    Total seconds elapsed as a decimal number, use %.3s to set the precision. (307804.999999999)
+	%R    Human readable time elapsed, use %.3R to set the precision of the seconds (default 1).
+	                                                                        (3d13h30m04.9s, 1h02m03.4s, 3.4s)
+	%A    Compact time elapsed, scales automatically.                       (850ms, 12.3s, 4m05s, 2h05m, 3d13h)
 	%%    A literal '%' character.                                          (%)
 
 	It is possible to to zero/space pad the directives
@@ -108,9 +133,88 @@ func main() {
 	`)
 	rootCmd.Flag("relative").NoOptDefVal = "[%X] "
 
-	rootCmd.Flags().StringVarP(&colorCorrection, "color-correction", "", "enable", "change color correction if you experience problems (possible values: enable, alternate, disable")
+	rootCmd.Flags().StringVarP(&opts.delta, "delta", "d", "", `use delta log mode, this means that the time since the previous line started is printed. You can use the same directives as in relative log mode
+	Examples:
+		$ ping 8.8.8.8 | logtimer --delta="[+%Xf] "
+		[+00:00:00.000012] PING 8.8.8.8 (8.8.8.8) 56(84) bytes of data.
+		[+00:00:00.016734] 64 bytes from 8.8.8.8: icmp_seq=1 ttl=123 time=16.7 ms
+		[+00:00:01.001235] 64 bytes from 8.8.8.8: icmp_seq=2 ttl=123 time=16.5 ms
+	`)
+	rootCmd.Flag("delta").NoOptDefVal = "[%X] "
+
+	rootCmd.Flags().StringVarP(&opts.colorCorrection, "color-correction", "", "auto", `change color correction if you experience problems (possible values: auto, enable, alternate, track, disable)
+	auto picks the color correction based on the output, TERM, NO_COLOR and CI
+	track does not move the cursor, use it when the output is written to a file, a pager or a CI log
+`)
+
+	rootCmd.Flags().StringVarP(&opts.stdoutFormat, "stdout-format", "", "", "format to prefix the stdout lines of a command, defaults to --format, --relative or --delta")
+	rootCmd.Flags().StringVarP(&opts.stderrFormat, "stderr-format", "", "", `format to prefix the stderr lines of a command, defaults to --format, --relative or --delta
+	Example:
+		logtimer --stderr-format="[%X] E " -- make build
+`)
+	rootCmd.Flags().StringVarP(&opts.stdoutColor, "stdout-color", "", "", "color of the prefix for stdout lines (possible values: "+strings.Join(logtimer.ColorNames(), ", ")+", 0-255 or #rrggbb)")
+	rootCmd.Flags().StringVarP(&opts.stderrColor, "stderr-color", "", "", "color of the prefix for stderr lines of a command (possible values: "+strings.Join(logtimer.ColorNames(), ", ")+", 0-255 or #rrggbb)")
+
+	rootCmd.Flags().StringVarP(&opts.layout, "layout", "", "", `format to prefix the lines, using a Go reference layout (see https://pkg.go.dev/time#Layout)
+	Example:
+		ping 8.8.8.8 | logtimer --layout="[2006-01-02T15:04:05.000Z07:00] "
+`)
+	rootCmd.Flags().StringVarP(&opts.preset, "preset", "", "", "use a named preset to prefix the lines (possible values: "+strings.Join(logtimer.PresetNames(), ", ")+")")
+
+	rootCmd.Flags().BoolVarP(&opts.utc, "utc", "", false, "format the time in UTC instead of the local time zone")
+	rootCmd.Flags().StringVarP(&opts.timeZone, "tz", "", "", `format the time in an IANA time zone instead of the local time zone
+	Example:
+		logtimer --tz=Europe/Berlin
+`)
+
+	rootCmd.Flags().StringVarP(&opts.locale, "locale", "", "", `locale of the day and month names and of %c, %x and %X, defaults to LC_ALL, LC_TIME or LANG (possible values: `+strings.Join(logtimer.LocaleNames(), ", ")+`)
+	Example:
+		logtimer --locale=de --format="[%a %c] "
+`)
+
+	rootCmd.Flags().StringVarP(&opts.carriageReturn, "carriage-return", "", "ignore", `handling of carriage returns that redraw a line, e.g. progress bars (possible values: ignore, restart, collapse, auto)
+	restart   prefix every redraw
+	collapse  only print the final state of a redrawn line, for files and pipes
+	auto      restart if the output is a terminal, collapse otherwise
+`)
+
+	rootCmd.Flags().StringVarP(&opts.delimiter, "delimiter", "", "lf", `delimiter of the records that are prefixed (possible values: lf, crlf, nul or a sequence like "\x1e" or "--\n")
+	Example:
+		find . -print0 | logtimer --delimiter=nul | xargs -0 ...
+`)
+
+	rootCmd.Flags().StringVarP(&opts.output, "output", "o", "text", `format of the output (possible values: text, json, logfmt, csv, tsv, inject)
+	text    prefix the lines with --format, --relative or --delta
+	json    write every line as a JSON object (JSON Lines) with the fields ts, elapsed_ns, delta_ns, line, stream and seq
+	logfmt  write every line as ts=... elapsed=... delta=... stream=... seq=... msg="..."
+	csv     write every line as a row with the columns ts, elapsed_ns, delta_ns, line, stream and seq
+	tsv     like csv, separated by tabs, tabs and line breaks in a field are escaped as \t, \n and \r
+	inject  insert the fields of --inject-field into lines that are JSON objects, prefix the other lines
+	Example:
+		logtimer --output=json -- make build | jq -r 'select(.stream == "stderr") | .line'
+`)
+	rootCmd.Flags().StringVarP(&opts.ansi, "ansi", "", "keep", `handling of escape sequences like colors in structured output (possible values: keep, strip, separate)
+	separate  strip them from line and store the original line in raw
+`)
+	rootCmd.Flags().StringArrayVarP(&opts.injectFields, "inject-field", "", []string{
+		"logtimer_ts=" + logtimer.Layout(time.RFC3339Nano),
+		"logtimer_elapsed=%{elapsed:.3s}",
+	}, `name=format of a field that --output=inject inserts into JSON lines, can be repeated
+	Example:
+		logtimer --output=inject --inject-field="ts=%FT%T%:z" --inject-field="delta=%{delta:R}" -- ./service
+`)
+	rootCmd.Flags().BoolVarP(&opts.header, "header", "", false, "write a header row with the column names for --output=csv and --output=tsv")
+
+	// --fake-clock makes the output reproducible for end-to-end tests
+	rootCmd.PersistentFlags().StringVarP(&opts.fakeClock, "fake-clock", "", "", "start[,step] of a clock that advances by step every time it is read, e.g. 2019-02-07T11:26:45Z,1s")
+	_ = rootCmd.PersistentFlags().MarkHidden("fake-clock")
+
+	rootCmd.Flags().SetInterspersed(false)
+
+	rootCmd.AddCommand(newRecordCommand(&opts, &exitCode), newReplayCommand(&opts))
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
 	}
+	os.Exit(exitCode)
 }
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// terminalSizeField matches the terminal size in the header of an asciicast, which depends on the terminal the
+// tests run in.
+var terminalSizeField = regexp.MustCompile(`"width":\d+,"height":\d+`)
+
+// logTime matches the time the log package prefixes errors with.
+var logTime = regexp.MustCompile(`(?m)^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `)
+
+func TestMain(m *testing.M) {
+	// the test binary runs logtimer itself, so the end-to-end tests do not need to build it
+	if os.Getenv("LOGTIMER_TEST_MAIN") == "1" {
+		main()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// mainCommand returns a command that runs logtimer with args in a reproducible environment.
+func mainCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], args...) //nolint: gosec // runs the test binary
+	cmd.Env = append(os.Environ(),
+		"LOGTIMER_TEST_MAIN=1",
+		"LANG=C",
+		"LC_ALL=",
+		"LC_TIME=",
+		"NO_COLOR=",
+		"TERM=xterm",
+		"SHELL=/bin/sh",
+	)
+	return cmd
+}
+
+// runMain runs logtimer with args and stdin and returns its output, stdout and stderr merged, and exit code.
+func runMain(t *testing.T, stdin string, args ...string) (string, int) {
+	t.Helper()
+	var out bytes.Buffer
+	code := runMainTo(t, &out, &out, stdin, args...)
+	return out.String(), code
+}
+
+// runMainTo runs logtimer with args and stdin, writes its stdout and stderr to the given writers and returns its
+// exit code.
+func runMainTo(t *testing.T, stdout, stderr io.Writer, stdin string, args ...string) int {
+	t.Helper()
+	cmd := mainCommand(args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	require.NoError(t, err)
+	return 0
+}
+
+func TestGolden(t *testing.T) {
+	const clock = "--fake-clock=2019-02-07T11:26:45Z,1500ms"
+	type goldenTest struct {
+		name  string
+		stdin string
+		args  []string
+	}
+	tests := []goldenTest{
+		{"format", "Hello\nWorld\n", []string{clock, "--utc", "--format=[%a, %d %b %Y %T %Z] "}},
+		{"relative", "Hello\nWorld\n", []string{clock, "--relative=[%Xf] "}},
+		{"delta", "Hello\nWorld\n", []string{clock, "--delta=[+%R] "}},
+		{"locale", "Hallo\n", []string{clock, "--utc", "--locale=de", "--format=[%a, %x %X] "}},
+		{"collapse", "10%\r50%\r100%\nfertig\n", []string{clock, "--relative", "--carriage-return=collapse"}},
+		{"delimiter", "a\x00b\x00", []string{clock, "--relative", "--delimiter=nul"}},
+		{"json", "Hello\n\x1b[31mWorld\x1b[0m\n", []string{clock, "--utc", "--output=json"}},
+		{"json-strip", "\x1b[31mHello\x1b[0m\n", []string{clock, "--utc", "--output=json", "--ansi=strip"}},
+		{"logfmt", "Hello \"World\"\n", []string{clock, "--utc", "--output=logfmt"}},
+		{"csv", "Hello, World\nsay \"hi\"\n", []string{clock, "--utc", "--output=csv", "--header"}},
+		{"tsv", "a\tb\n", []string{clock, "--utc", "--output=tsv", "--header", "--ansi=separate"}},
+		{"inject", "{\"level\":\"info\",\"msg\":\"started\"}\nnot json\n", []string{clock, "--utc", "--output=inject"}},
+		{"inject-fields", "{}\n", []string{clock, "--output=inject", "--inject-field=elapsed=%{elapsed:R}",
+			"--inject-field=delta=%{delta:R}"}},
+		{"replay-json", "", []string{"replay", "--speed=1000x", filepath.Join("testdata", "replay.jsonl")}},
+		{"replay-asciicast", "", []string{"replay", "--max-idle=1ms", filepath.Join("testdata", "replay.cast")}},
+		{"invalid-speed", "", []string{"replay", "--speed=fast", filepath.Join("testdata", "replay.jsonl")}},
+		{"invalid-header", "", []string{clock, "--header"}},
+		{"invalid-format", "", []string{clock, "--format=[%Q] "}},
+		{"invalid-format-wide", "", []string{clock, "--format=\t日本 %Q"}},
+		{"invalid-format-json", "", []string{clock, "--output=json", "--format=[%Q] "}},
+		{"invalid-stderr-format", "", []string{clock, "--stderr-format=[%Q] "}},
+		{"invalid-color", "", []string{clock, "--stderr-color=pink"}},
+		{"invalid-color-correction", "", []string{clock, "--color-correction=tracknig"}},
+	}
+	if runtime.GOOS != "windows" {
+		// the streams of a command are read concurrently and separate writes may arrive with one read, so each
+		// command writes its lines at once to one stream to keep the readings of the clock stable, see
+		// TestExecMerge for both streams
+		tests = append(tests,
+			goldenTest{"command", "", []string{clock, "--relative", "--stderr-format=[%X] E ", "--",
+				"sh", "-c", "printf 'out\\nmore\\n'; exit 3"}},
+			goldenTest{"command-stderr", "", []string{clock, "--relative", "--stderr-format=[%X] E ", "--",
+				"sh", "-c", "printf 'err\\nmore\\n' >&2"}},
+			goldenTest{"command-json", "", []string{clock, "--utc", "--output=json", "--",
+				"sh", "-c", "echo err >&2"}},
+			goldenTest{"command-json-nul", "", []string{clock, "--utc", "--output=json", "--delimiter=nul", "--",
+				"sh", "-c", "printf 'a\\0b\\0' >&2"}},
+		)
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			out, code := runMain(t, test.stdin, test.args...)
+			requireGolden(t, test.name, out, code)
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the recorded command needs a shell")
+	}
+	const clock = "--fake-clock=2019-02-07T11:26:45Z,1500ms"
+
+	t.Run("Golden", func(t *testing.T) {
+		// one write to one stream keeps the events stable, see Streams for both streams
+		cast := filepath.Join(t.TempDir(), "out.cast")
+		var stdout, stderr bytes.Buffer
+		code := runMainTo(t, &stdout, &stderr, "", "record", "--asciicast", cast, clock, "--",
+			"sh", "-c", "printf 'out\\n\\033[31mred\\033[0m\\n'; exit 3")
+		require.Equal(t, 3, code)
+		require.Equal(t, "out\n\x1b[31mred\x1b[0m\n", stdout.String())
+		require.Empty(t, stderr.String())
+
+		b, err := os.ReadFile(cast)
+		require.NoError(t, err)
+		requireGolden(t, "record", terminalSizeField.ReplaceAllString(string(b), `"width":80,"height":24`), code)
+	})
+
+	t.Run("Streams", func(t *testing.T) {
+		cast := filepath.Join(t.TempDir(), "out.cast")
+		var stdout, stderr bytes.Buffer
+		code := runMainTo(t, &stdout, &stderr, "", "record", "--asciicast", cast, clock, "--",
+			"sh", "-c", "echo out; echo err >&2")
+		require.Equal(t, 0, code)
+		require.Equal(t, "out\n", stdout.String())
+		require.Equal(t, "err\n", stderr.String())
+
+		// the streams are read concurrently, so the order of their events is not known
+		b, err := os.ReadFile(cast)
+		require.NoError(t, err)
+		events := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")[1:]
+		require.Len(t, events, 2)
+		var data []string
+		for _, event := range events {
+			_, value, ok := strings.Cut(event, `"o", `)
+			require.True(t, ok, event)
+			data = append(data, value)
+		}
+		require.ElementsMatch(t, []string{`"out\r\n"]`, `"err\r\n"]`}, data)
+	})
+
+	t.Run("Event Order", func(t *testing.T) {
+		cast := filepath.Join(t.TempDir(), "out.cast")
+		_, code := runMain(t, "", "record", "--asciicast", cast, clock, "--",
+			"sh", "-c", "i=0; while [ $i -lt 5000 ]; do echo o; echo e >&2; i=$((i+1)); done")
+		require.Equal(t, 0, code)
+
+		// the streams are read concurrently, but the events are stamped in the order they are written
+		b, err := os.ReadFile(cast)
+		require.NoError(t, err)
+		var previous float64
+		var data strings.Builder
+		for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")[1:] {
+			var event []any
+			require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+			require.Len(t, event, 3, line)
+			require.GreaterOrEqual(t, event[0], previous, line)
+			previous = event[0].(float64)
+			data.WriteString(event[2].(string))
+		}
+		require.Equal(t, 5000, strings.Count(data.String(), "o\r\n"))
+		require.Equal(t, 5000, strings.Count(data.String(), "e\r\n"))
+	})
+
+	t.Run("Command Not Found", func(t *testing.T) {
+		cast := filepath.Join(t.TempDir(), "out.cast")
+		require.NoError(t, os.WriteFile(cast, []byte("previous recording"), 0o600))
+		_, code := runMain(t, "", "record", "--asciicast", cast, "--", "logtimer-does-not-exist")
+		require.Equal(t, 1, code)
+
+		// the file is created once the command runs
+		b, err := os.ReadFile(cast)
+		require.NoError(t, err)
+		require.Equal(t, "previous recording", string(b))
+	})
+}
+
+// requireGolden compares the output and exit code of logtimer with the golden file of name, with -update the golden
+// file is written.
+func requireGolden(t *testing.T, name, out string, code int) {
+	t.Helper()
+	// the log package prefixes errors with the current time
+	out = logTime.ReplaceAllString(out, "")
+	out = strings.Join([]string{out, "exit code: " + strconv.Itoa(code), ""}, "\n")
+
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, []byte(out), 0o600))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), out)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/Eun/logtimer"
+	"github.com/spf13/cobra"
+)
+
+// The size of the terminal if it cannot be determined.
+const (
+	defaultColumns = 80
+	defaultRows    = 24
+)
+
+// recordedEnv are the environment variables that are stored in the header of a recording.
+var recordedEnv = []string{"SHELL", "TERM"}
+
+// newRecordCommand returns the record command, it records the output of a command as an asciicast.
+func newRecordCommand(opts *options, exitCode *int) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "record --asciicast file [flags] -- command [args...]",
+		Short: "record the output of a command with its timing",
+		Long: `record the output of a command as an asciicast v2 file that can be played with asciinema or logtimer replay.
+The output of the command is also written to stdout.
+	Example:
+		logtimer record --asciicast build.cast -- make build
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			*exitCode, err = opts.record(args)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&opts.asciicast, "asciicast", "", "", "file to write the asciicast v2 recording to")
+	_ = cmd.MarkFlagRequired("asciicast")
+	cmd.Flags().SetInterspersed(false)
+	return cmd
+}
+
+// record runs the command described by args, records its output to the file set by --asciicast and returns the
+// exit code of the command.
+func (o *options) record(args []string) (int, error) {
+	clock, err := o.clock()
+	if err != nil {
+		return 0, err
+	}
+	timer := logtimer.NewTimerWithClock(clock)
+
+	// the recording is created once the command runs, so a command that cannot be started keeps an existing file
+	var f *os.File
+	open := func() (io.Writer, error) {
+		var err error
+		f, err = os.Create(o.asciicast)
+		if err != nil {
+			return nil, err
+		}
+		b, err := asciicastHeader(timer, args).AppendJSON(nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(b); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	defer func() {
+		// the file is closed below to report write errors, closing it twice is harmless
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+
+	// the output of the command is passed on to the terminal
+	newWriter := func(terminal io.Writer) func(io.Writer) io.WriteCloser {
+		return func(w io.Writer) io.WriteCloser {
+			return &recordingWriter{
+				terminal: terminal,
+				AsciicastWriter: &logtimer.AsciicastWriter{
+					Writer: w,
+					Timer:  timer,
+					Clock:  clock,
+					// the command writes to a pipe, which does not translate line feeds like a terminal
+					CRLF: true,
+				},
+			}
+		}
+	}
+	code, err := runCommand(open, args, newWriter(os.Stdout), newWriter(os.Stderr))
+	if err != nil {
+		return code, err
+	}
+	if err := f.Close(); err != nil {
+		return code, fmt.Errorf("unable to write %s: %w", o.asciicast, err)
+	}
+	return code, nil
+}
+
+// asciicastHeader returns the header of the recording of the command described by args.
+func asciicastHeader(timer *logtimer.Timer, args []string) logtimer.AsciicastHeader {
+	columns, rows := terminalSize(os.Getenv)
+	header := logtimer.AsciicastHeader{
+		Width:     columns,
+		Height:    rows,
+		Timestamp: timer.Start(),
+		Command:   strings.Join(args, " "),
+		Env:       map[string]string{},
+	}
+	for _, key := range recordedEnv {
+		if v, ok := os.LookupEnv(key); ok {
+			header.Env[key] = v
+		}
+	}
+	return header
+}
+
+// recordingWriter writes the output of a stream to the terminal and records it.
+type recordingWriter struct {
+	terminal io.Writer
+	*logtimer.AsciicastWriter
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	_, _ = rw.terminal.Write(p)
+	return rw.AsciicastWriter.Write(p)
+}
+
+// terminalSize returns the columns and rows of the terminal, it asks stty, then uses COLUMNS and LINES and falls
+// back to 80x24.
+func terminalSize(getenv func(string) string) (columns, rows int) {
+	if columns, rows, ok := sttySize(); ok {
+		return columns, rows
+	}
+	columns, err := strconv.Atoi(getenv("COLUMNS"))
+	if err != nil || columns <= 0 {
+		columns = defaultColumns
+	}
+	rows, err = strconv.Atoi(getenv("LINES"))
+	if err != nil || rows <= 0 {
+		rows = defaultRows
+	}
+	return columns, rows
+}
+
+// sttySize returns the size of the controlling terminal reported by stty size.
+func sttySize() (columns, rows int, ok bool) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return 0, 0, false
+	}
+	defer tty.Close()
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, false
+	}
+	// stty prints "rows columns"
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	rows, err = strconv.Atoi(fields[0])
+	if err != nil || rows <= 0 {
+		return 0, 0, false
+	}
+	columns, err = strconv.Atoi(fields[1])
+	if err != nil || columns <= 0 {
+		return 0, 0, false
+	}
+	return columns, rows, true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Eun/logtimer"
+	"github.com/spf13/cobra"
+)
+
+// newReplayCommand returns the replay command, it replays a timed log with its original timing.
+func newReplayCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay [flags] file",
+		Short: "replay a log written with --output=json or a recording with its original timing",
+		Long: `replay a log written with --output=json or an asciicast recording written by logtimer record with its original timing.
+In a terminal the replay can be controlled with the keyboard:
+	space  pause and resume
+	.      show the next line, also while paused
+	q      quit
+	Example:
+		logtimer replay --speed=4x --max-idle=2s build.cast
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.replay(args[0])
+		},
+	}
+	cmd.Flags().StringVarP(&opts.speed, "speed", "", "1x", "speed of the replay, e.g. 4x or 0.5x")
+	cmd.Flags().DurationVarP(&opts.maxIdle, "max-idle", "", 0, "maximum time between two lines, e.g. 2s, 0 keeps the original time")
+	return cmd
+}
+
+// replay replays the timed log in the file name to stdout.
+func (o *options) replay(name string) error {
+	speed, err := o.replaySpeed()
+	if err != nil {
+		return err
+	}
+	if o.maxIdle < 0 {
+		return errors.New("invalid --max-idle: must not be negative")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := logtimer.Replayer{
+		Speed:   speed,
+		MaxIdle: o.maxIdle,
+	}
+	if logtimer.IsTerminal(os.Stdout) {
+		keys, restore, err := openKeys()
+		if err == nil {
+			defer restore()
+			r.Keys = keys
+		}
+	}
+	return r.Replay(os.Stdout, logtimer.NewEventDecoder(f))
+}
+
+// replaySpeed returns the speed set by --speed.
+func (o *options) replaySpeed() (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(o.speed), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid --speed: %q is not a speed like 4x or 0.5x", o.speed)
+	}
+	return speed, nil
+}
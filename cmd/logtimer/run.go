@@ -0,0 +1,562 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Eun/logtimer"
+)
+
+// options holds the command line flags.
+type options struct {
+	format          string
+	relative        string
+	delta           string
+	colorCorrection string
+	stdoutFormat    string
+	stderrFormat    string
+	stdoutColor     string
+	stderrColor     string
+	utc             bool
+	timeZone        string
+	layout          string
+	preset          string
+	locale          string
+	carriageReturn  string
+	delimiter       string
+	fakeClock       string
+	output          string
+	ansi            string
+	header          bool
+	injectFields    []string
+	asciicast       string
+	speed           string
+	maxIdle         time.Duration
+
+	// formatSet tells whether --format was given, it has a default
+	formatSet bool
+}
+
+// supportsStyles reports whether styles and colors are written to w, tests replace it to check the colored output.
+var supportsStyles = logtimer.SupportsStyles
+
+// run prefixes stdin, or the output of the command described by args, and returns the exit code.
+func (o *options) run(args []string) (int, error) {
+	if _, err := o.location(); err != nil {
+		return 0, err
+	}
+	if _, err := o.timeLocale(); err != nil {
+		return 0, err
+	}
+	if _, err := o.colorCorrectionMode(); err != nil {
+		return 0, err
+	}
+	if _, err := o.carriageReturnMode(); err != nil {
+		return 0, err
+	}
+	if _, err := o.recordDelimiter(); err != nil {
+		return 0, err
+	}
+	if err := o.validateFormats(); err != nil {
+		return 0, err
+	}
+
+	clock, err := o.clock()
+	if err != nil {
+		return 0, err
+	}
+	timer := logtimer.NewTimerWithClock(clock)
+
+	if err := o.writeHeader(os.Stdout); err != nil {
+		return 0, err
+	}
+
+	if len(args) > 0 {
+		stdoutWriter, err := o.newStreamWriter(timer, clock, "stdout", o.stdoutFormat, o.stdoutColor)
+		if err != nil {
+			return 0, err
+		}
+		stderrWriter, err := o.newStreamWriter(timer, clock, "stderr", o.stderrFormat, o.stderrColor)
+		if err != nil {
+			return 0, err
+		}
+		return runCommand(func() (io.Writer, error) { return os.Stdout, nil }, args, stdoutWriter, stderrWriter)
+	}
+
+	newStdinReader, err := o.newStreamReader(timer, clock, "stdin", "", o.stdoutColor)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(os.Stdout, newStdinReader(os.Stdin))
+	return 0, nil
+}
+
+// validateFormats reports an invalid format or color, including those that are not used by the mode logtimer runs
+// in, e.g. --stderr-format while reading stdin.
+func (o *options) validateFormats() error {
+	for _, stream := range []string{"stdout", "stderr"} {
+		f, color := o.stdoutFormat, o.stdoutColor
+		if stream == "stderr" {
+			f, color = o.stderrFormat, o.stderrColor
+		}
+		if _, err := o.prefixFormatter(stream, f, color); err != nil {
+			return err
+		}
+	}
+	if len(o.injectFields) > 0 {
+		if _, err := o.injectEncoder("stdout", o.stdoutFormat, o.stdoutColor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// colorCorrectionMode returns how colors are corrected around the prefix, see --color-correction.
+func (o *options) colorCorrectionMode() (logtimer.ColorCorrection, error) {
+	switch strings.ToLower(o.colorCorrection) {
+	case "auto":
+		return logtimer.DetectColorCorrection(os.Stdout), nil
+	case "true", "normal", "standard", "enable":
+		return logtimer.Enabled, nil
+	case "alternate":
+		return logtimer.Alternate, nil
+	case "track", "tracking":
+		return logtimer.Tracking, nil
+	case "", "false", "disable", "disabled":
+		return logtimer.Disabled, nil
+	default:
+		return 0, fmt.Errorf("invalid --color-correction: unknown mode %q", o.colorCorrection)
+	}
+}
+
+// carriageReturnMode returns how carriage returns are handled, see --carriage-return.
+func (o *options) carriageReturnMode() (logtimer.CarriageReturn, error) {
+	switch strings.ToLower(o.carriageReturn) {
+	case "", "ignore":
+		return logtimer.IgnoreCarriageReturn, nil
+	case "restart":
+		return logtimer.RestartCarriageReturn, nil
+	case "collapse":
+		return logtimer.CollapseCarriageReturn, nil
+	case "auto":
+		if logtimer.IsTerminal(os.Stdout) {
+			return logtimer.RestartCarriageReturn, nil
+		}
+		return logtimer.CollapseCarriageReturn, nil
+	default:
+		return 0, fmt.Errorf("invalid --carriage-return: unknown mode %q", o.carriageReturn)
+	}
+}
+
+// recordDelimiter returns the delimiter set by --delimiter.
+func (o *options) recordDelimiter() ([]byte, error) {
+	switch strings.ToLower(o.delimiter) {
+	case "", "lf", "newline":
+		return []byte("\n"), nil
+	case "crlf":
+		return []byte("\r\n"), nil
+	case "nul", "null":
+		return []byte{0}, nil
+	}
+	d, err := strconv.Unquote(`"` + o.delimiter + `"`)
+	if err != nil || d == "" {
+		return nil, fmt.Errorf("invalid --delimiter: %q is neither lf, crlf, nul nor a sequence", o.delimiter)
+	}
+	return []byte(d), nil
+}
+
+// clock returns the clock set by --fake-clock, or the system clock.
+// A fake clock is set as start[,step], e.g. 2019-02-07T11:26:45Z,1s, it advances by step every time it is read.
+func (o *options) clock() (logtimer.Clock, error) {
+	if o.fakeClock == "" {
+		return logtimer.SystemClock, nil
+	}
+	value, stepValue, hasStep := strings.Cut(o.fakeClock, ",")
+	start, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --fake-clock: %w", err)
+	}
+	var step time.Duration
+	if hasStep {
+		step, err = time.ParseDuration(stepValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --fake-clock: %w", err)
+		}
+	}
+	return logtimer.NewFakeClock(start, step), nil
+}
+
+// location returns the time zone set by --utc or --tz, nil means local time.
+func (o *options) location() (*time.Location, error) {
+	if o.utc && o.timeZone != "" {
+		return nil, errors.New("--utc and --tz cannot be used together")
+	}
+	if o.utc {
+		return time.UTC, nil
+	}
+	if o.timeZone == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(o.timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --tz: %w", err)
+	}
+	return loc, nil
+}
+
+// timeLocale returns the locale set by --locale, or the one of the environment.
+func (o *options) timeLocale() (*logtimer.Locale, error) {
+	if o.locale == "" {
+		return logtimer.EnvironmentLocale(), nil
+	}
+	l, ok := logtimer.LookupLocale(o.locale)
+	if !ok {
+		return nil, fmt.Errorf("invalid --locale: unknown locale %q", o.locale)
+	}
+	return l, nil
+}
+
+// mainFormat returns the format that is used when no stream specific format is set, the flag it was set with
+// and the namespace for its directives.
+func (o *options) mainFormat() (format, flag, namespace string, err error) {
+	var set []string
+	for _, f := range []struct {
+		flag string
+		set  bool
+	}{
+		{"format", o.formatSet},
+		{"relative", o.relative != ""},
+		{"delta", o.delta != ""},
+		{"layout", o.layout != ""},
+		{"preset", o.preset != ""},
+	} {
+		if f.set {
+			set = append(set, "--"+f.flag)
+		}
+	}
+	if len(set) > 1 {
+		return "", "", "", fmt.Errorf("%s and %s cannot be used together", set[0], set[1])
+	}
+
+	switch {
+	case o.delta != "":
+		return o.delta, "delta", logtimer.DeltaNamespace, nil
+	case o.relative != "":
+		return o.relative, "relative", logtimer.ElapsedNamespace, nil
+	case o.layout != "":
+		if strings.Contains(o.layout, "}") {
+			return "", "", "", errors.New("invalid --layout: a layout must not contain }")
+		}
+		return logtimer.Layout(o.layout), "layout", logtimer.TimeNamespace, nil
+	case o.preset != "":
+		f, ok := logtimer.Preset(o.preset)
+		if !ok {
+			return "", "", "", fmt.Errorf("invalid --preset: unknown preset %q", o.preset)
+		}
+		return f + " ", "preset", logtimer.TimeNamespace, nil
+	default:
+		return o.format, "format", logtimer.TimeNamespace, nil
+	}
+}
+
+// newStreamReader returns a function that wraps the reader of stream, it either prefixes the lines using the format
+// f and color or encodes them as set by --output.
+func (o *options) newStreamReader(timer *logtimer.Timer, clock logtimer.Clock, stream, f, color string) (func(io.Reader) io.Reader, error) {
+	// stdin is prefixed like stdout, see --stdout-color
+	prefixStream := stream
+	if stream == "stdin" {
+		prefixStream = "stdout"
+	}
+	encoder, err := o.streamEncoder(prefixStream, f, color)
+	if err != nil {
+		return nil, err
+	}
+	if encoder == nil {
+		return o.newReader(timer, clock, prefixStream, f, color)
+	}
+	return o.newRecordReader(timer, clock, stream, encoder)
+}
+
+// newStreamWriter is the io.Writer counterpart of newStreamReader, it is used for the output of a command.
+func (o *options) newStreamWriter(timer *logtimer.Timer, clock logtimer.Clock, stream, f, color string) (func(io.Writer) io.WriteCloser, error) {
+	encoder, err := o.streamEncoder(stream, f, color)
+	if err != nil {
+		return nil, err
+	}
+	if encoder == nil {
+		return o.newWriter(timer, clock, stream, f, color)
+	}
+	return o.newRecordWriter(timer, clock, stream, encoder)
+}
+
+// streamEncoder returns the encoder of the lines of stream set by --output, or nil if the lines are prefixed.
+func (o *options) streamEncoder(stream, f, color string) (logtimer.RecordEncoder, error) {
+	switch strings.ToLower(o.output) {
+	case "", "text":
+		return nil, nil
+	case "inject":
+		return o.injectEncoder(stream, f, color)
+	default:
+		return o.recordEncoder()
+	}
+}
+
+// recordEncoder returns the encoder of the lines set by --output.
+func (o *options) recordEncoder() (logtimer.RecordEncoder, error) {
+	loc, err := o.location()
+	if err != nil {
+		return nil, err
+	}
+	ansi, err := o.ansiMode()
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(o.output) {
+	case "json", "jsonl":
+		return logtimer.JSONEncoder{Location: loc, ANSI: ansi}, nil
+	case "logfmt":
+		return logtimer.LogfmtEncoder{Location: loc, ANSI: ansi}, nil
+	case "csv":
+		return logtimer.CSVEncoder{Location: loc, ANSI: ansi}, nil
+	case "tsv":
+		return logtimer.TSVEncoder{Location: loc, ANSI: ansi}, nil
+	default:
+		return nil, fmt.Errorf("invalid --output: unknown output %q", o.output)
+	}
+}
+
+// injectEncoder returns the encoder of --output=inject, it inserts the fields of --inject-field into JSON lines and
+// prefixes the other lines of stream with the format f in color.
+func (o *options) injectEncoder(stream, f, color string) (logtimer.RecordEncoder, error) {
+	fallback, err := o.prefixFormatter(stream, f, color)
+	if err != nil {
+		return nil, err
+	}
+	c, err := o.compiler(logtimer.TimeNamespace)
+	if err != nil {
+		return nil, err
+	}
+	// the values are JSON strings, escape sequences do not belong in there
+	c.NoStyles = true
+	fields := make([]logtimer.JSONField, 0, len(o.injectFields))
+	for _, field := range o.injectFields {
+		name, format, ok := strings.Cut(field, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --inject-field: %q is not name=format", field)
+		}
+		formatter, err := c.Compile(format)
+		if err != nil {
+			return nil, flagError("inject-field", err)
+		}
+		fields = append(fields, logtimer.JSONField{Name: name, Format: formatter})
+	}
+	return logtimer.JSONInjectEncoder{Fields: fields, Fallback: fallback}, nil
+}
+
+// writeHeader writes the header of the output to w if --header is set.
+func (o *options) writeHeader(w io.Writer) error {
+	if !o.header {
+		return nil
+	}
+	encoder, err := o.recordEncoder()
+	if err != nil {
+		return errors.New("--header can only be used with --output=csv or --output=tsv")
+	}
+	headerEncoder, ok := encoder.(logtimer.HeaderEncoder)
+	if !ok {
+		return fmt.Errorf("--header cannot be used with --output=%s", o.output)
+	}
+	_, err = w.Write(headerEncoder.AppendHeader(nil))
+	return err
+}
+
+// newRecordReader returns a function that wraps the reader of stream into a RecordReader that encodes the lines
+// with encoder.
+func (o *options) newRecordReader(timer *logtimer.Timer, clock logtimer.Clock, stream string,
+	encoder logtimer.RecordEncoder) (func(io.Reader) io.Reader, error) {
+	cr, err := o.carriageReturnMode()
+	if err != nil {
+		return nil, err
+	}
+	delimiter, err := o.recordDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	return func(r io.Reader) io.Reader {
+		return &logtimer.RecordReader{
+			Reader:         r,
+			Encoder:        encoder,
+			Timer:          timer,
+			Stream:         stream,
+			CarriageReturn: cr,
+			Clock:          clock,
+			Delimiter:      delimiter,
+		}
+	}, nil
+}
+
+// newRecordWriter returns a function that wraps a writer into a RecordWriter that encodes the lines of stream with
+// encoder.
+func (o *options) newRecordWriter(timer *logtimer.Timer, clock logtimer.Clock, stream string,
+	encoder logtimer.RecordEncoder) (func(io.Writer) io.WriteCloser, error) {
+	cr, err := o.carriageReturnMode()
+	if err != nil {
+		return nil, err
+	}
+	delimiter, err := o.recordDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer) io.WriteCloser {
+		return &logtimer.RecordWriter{
+			Writer:         w,
+			Encoder:        encoder,
+			Timer:          timer,
+			Stream:         stream,
+			CarriageReturn: cr,
+			Clock:          clock,
+			Delimiter:      delimiter,
+		}
+	}, nil
+}
+
+// ansiMode returns how escape sequences are encoded, see --ansi.
+func (o *options) ansiMode() (logtimer.ANSI, error) {
+	switch strings.ToLower(o.ansi) {
+	case "", "keep":
+		return logtimer.KeepANSI, nil
+	case "strip":
+		return logtimer.StripANSI, nil
+	case "separate":
+		return logtimer.SeparateANSI, nil
+	default:
+		return 0, fmt.Errorf("invalid --ansi: unknown mode %q", o.ansi)
+	}
+}
+
+// compiler returns the Compiler for formats whose directives default to namespace.
+func (o *options) compiler(namespace string) (logtimer.Compiler, error) {
+	loc, err := o.location()
+	if err != nil {
+		return logtimer.Compiler{}, err
+	}
+	l, err := o.timeLocale()
+	if err != nil {
+		return logtimer.Compiler{}, err
+	}
+	return logtimer.Compiler{
+		DefaultNamespace: namespace,
+		Location:         loc,
+		Locale:           l,
+		NoStyles:         !supportsStyles(os.Stdout),
+	}, nil
+}
+
+// prefixFormatter compiles the prefix format f of stream in color, which were set with --<stream>-format and
+// --<stream>-color. If f is empty the main format is used.
+func (o *options) prefixFormatter(stream, f, color string) (*logtimer.Formatter, error) {
+	mainFormat, mainFlag, namespace, err := o.mainFormat()
+	if err != nil {
+		return nil, err
+	}
+	flag := stream + "-format"
+	if f == "" {
+		f, flag = mainFormat, mainFlag
+	}
+	c, err := o.compiler(namespace)
+	if err != nil {
+		return nil, err
+	}
+	formatter, err := c.Compile(f)
+	if err != nil {
+		return nil, flagError(flag, err)
+	}
+	if color == "" {
+		return formatter, nil
+	}
+	// the color wraps the format, so it is dropped like the styling directives of the format
+	colored := "%{" + logtimer.ForegroundNamespace + ":" + color + "}"
+	if strings.Contains(color, "}") || c.Validate(colored) != nil {
+		return nil, fmt.Errorf("invalid --%s-color: unknown color %q", stream, color)
+	}
+	return c.Compile(colored + f + "%{reset}")
+}
+
+// newReader returns a function that wraps a reader of stream into a PrefixReader using the format f in color, the
+// lines are stamped with the arrival time read from clock.
+func (o *options) newReader(timer *logtimer.Timer, clock logtimer.Clock, stream, f, color string) (func(io.Reader) io.Reader, error) {
+	formatter, err := o.prefixFormatter(stream, f, color)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := o.colorCorrectionMode()
+	if err != nil {
+		return nil, err
+	}
+	cr, err := o.carriageReturnMode()
+	if err != nil {
+		return nil, err
+	}
+	delimiter, err := o.recordDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	formatAt := formatter.FormatAtFunc(timer)
+	return func(r io.Reader) io.Reader {
+		return &logtimer.PrefixReader{
+			Reader:          r,
+			FormatAt:        formatAt,
+			DeferPrefix:     true,
+			Clock:           clock,
+			ColorCorrection: cc,
+			CarriageReturn:  cr,
+			Delimiter:       delimiter,
+		}
+	}, nil
+}
+
+// newWriter returns a function that wraps a writer into a PrefixWriter that prefixes the lines of stream using the
+// format f in color, the lines are stamped with the time they are written read from clock.
+func (o *options) newWriter(timer *logtimer.Timer, clock logtimer.Clock, stream, f, color string) (func(io.Writer) io.WriteCloser, error) {
+	formatter, err := o.prefixFormatter(stream, f, color)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := o.colorCorrectionMode()
+	if err != nil {
+		return nil, err
+	}
+	cr, err := o.carriageReturnMode()
+	if err != nil {
+		return nil, err
+	}
+	delimiter, err := o.recordDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	formatAt := formatter.FormatAtFunc(timer)
+	return func(w io.Writer) io.WriteCloser {
+		return &logtimer.PrefixWriter{
+			Writer:          w,
+			FormatAt:        formatAt,
+			Clock:           clock,
+			ColorCorrection: cc,
+			CarriageReturn:  cr,
+			Delimiter:       delimiter,
+		}
+	}, nil
+}
+
+// flagError describes an invalid format that was set with flag, the invalid directive is marked.
+func flagError(flag string, err error) error {
+	var formatError *logtimer.FormatError
+	if !errors.As(err, &formatError) {
+		return fmt.Errorf("invalid --%s: %w", flag, err)
+	}
+	return fmt.Errorf("invalid --%s: %w\n\t%s\n\t%s", flag, err, formatError.Format, formatError.Caret())
+}
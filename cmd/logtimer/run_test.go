@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Eun/logtimer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamReader(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+	// stdout is not a terminal while testing, pretend it supports colors
+	defer func(f func(io.Writer) bool) { supportsStyles = f }(supportsStyles)
+	supportsStyles = func(io.Writer) bool { return true }
+
+	tests := []struct {
+		name     string
+		opts     options
+		stream   string
+		expected string
+	}{
+		{"Main Format", options{relative: "[%X] "}, "stdout", "[00:00:01] a\n[00:00:01] b\n"},
+		{"Stdout Format", options{relative: "[%X] ", stdoutFormat: "O "}, "stdout", "O a\nO b\n"},
+		{"Stderr Format", options{relative: "[%X] ", stdoutFormat: "O ", stderrFormat: "E "}, "stderr", "E a\nE b\n"},
+		{"Stdout Color", options{stdoutFormat: "O ", stdoutColor: "red"}, "stdout",
+			"\x1b[31mO \x1b[0ma\n\x1b[31mO \x1b[0mb\n"},
+		{"Stderr Color", options{stderrFormat: "E ", stdoutColor: "red", stderrColor: "yellow"}, "stderr",
+			"\x1b[33mE \x1b[0ma\n\x1b[33mE \x1b[0mb\n"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			f, color := test.opts.stdoutFormat, test.opts.stdoutColor
+			if test.stream == "stderr" {
+				f, color = test.opts.stderrFormat, test.opts.stderrColor
+			}
+			clock := logtimer.NewFakeClock(start, time.Second)
+			newReader, err := test.opts.newStreamReader(logtimer.NewTimerWithClock(clock), clock, test.stream, f, color)
+			require.NoError(t, err)
+			out, err := io.ReadAll(newReader(strings.NewReader("a\nb\n")))
+			require.NoError(t, err)
+			require.Equal(t, test.expected, string(out))
+		})
+	}
+}
+
+func TestMainFormatConflicts(t *testing.T) {
+	tests := []struct {
+		opts     options
+		expected string
+	}{
+		{options{format: "[%T] ", formatSet: true, relative: "[%X] "}, "--format and --relative cannot be used together"},
+		{options{format: "[%T] ", formatSet: true, delta: "[%X] "}, "--format and --delta cannot be used together"},
+		{options{format: "[%T] ", formatSet: true, layout: "15:04"}, "--format and --layout cannot be used together"},
+		{options{format: "[%T] ", formatSet: true, preset: "iso"}, "--format and --preset cannot be used together"},
+		{options{relative: "[%X] ", delta: "[%X] "}, "--relative and --delta cannot be used together"},
+		{options{relative: "[%X] ", layout: "15:04"}, "--relative and --layout cannot be used together"},
+		{options{relative: "[%X] ", preset: "iso"}, "--relative and --preset cannot be used together"},
+		{options{delta: "[%X] ", layout: "15:04"}, "--delta and --layout cannot be used together"},
+		{options{delta: "[%X] ", preset: "iso"}, "--delta and --preset cannot be used together"},
+		{options{layout: "15:04", preset: "iso"}, "--layout and --preset cannot be used together"},
+	}
+	for _, test := range tests {
+		_, _, _, err := test.opts.mainFormat()
+		require.EqualError(t, err, test.expected)
+	}
+
+	// the default of --format does not conflict
+	f, flag, _, err := (&options{format: "[%X] ", relative: "[%Xf] "}).mainFormat()
+	require.NoError(t, err)
+	require.Equal(t, "[%Xf] ", f)
+	require.Equal(t, "relative", flag)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardedSignals are passed on to a command started by logtimer.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// exitCode returns the exit code of a finished command.
+func exitCode(err *exec.ExitError) int {
+	return err.ExitCode()
+}
@@ -0,0 +1,653 @@
+package logtimer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Errors that describe why a directive is invalid, see FormatError.
+var (
+	ErrIncompleteDirective = errors.New("incomplete directive (use %% for a literal %)")
+	ErrUnknownDirective    = errors.New("unknown directive")
+	ErrUnknownNamespace    = errors.New("unknown namespace")
+	ErrUnknownTimeZone     = errors.New("unknown time zone")
+	ErrInvalidPadding      = errors.New("invalid padding")
+	ErrUnknownColor        = errors.New("unknown color")
+)
+
+// FormatError is returned by Compile if a format contains an invalid directive.
+type FormatError struct {
+	// Format is the format that was compiled.
+	Format string
+	// Directive is the invalid directive.
+	Directive string
+	// Pos is the position of Directive in Format, counted in characters starting at 0.
+	Pos int
+	// Err describes why the directive is invalid.
+	Err error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("directive %q at position %d: %s", e.Directive, e.Pos, e.Err)
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+// Caret returns a line that marks Directive with a ^ if it is printed below Format. The characters before the
+// directive are replaced by as many spaces as terminal cells they take, tabs are kept so they line up.
+func (e *FormatError) Caret() string {
+	var b strings.Builder
+	pos := 0
+	for _, r := range e.Format {
+		if pos == e.Pos {
+			break
+		}
+		pos++
+		if r == '\t' {
+			_ = b.WriteByte('\t')
+			continue
+		}
+		_, _ = b.WriteString(strings.Repeat(" ", runeWidth(r)))
+	}
+	_ = b.WriteByte('^')
+	return b.String()
+}
+
+// Compiler compiles format strings into Formatters.
+// A typical example could be:
+//
+//	c := Compiler{
+//	    DefaultNamespace: ElapsedNamespace,
+//	}
+//	f, err := c.Compile("[%X %{time:X}] ")
+type Compiler struct {
+	// DefaultNamespace is used to resolve directives that are not qualified with a namespace,
+	// the default value is TimeNamespace.
+	DefaultNamespace string
+	// Location is the time zone the directives of TimeNamespace are formatted in,
+	// if it is nil the location of the Stamp is used.
+	Location *time.Location
+	// Locale is used for the names and the representations of TimeNamespace, if it is nil English is used.
+	Locale *Locale
+	// NoStyles drops the styling directives (%{fg:cyan}, %{bold}, %{reset}, ...), e.g. if the output is not a
+	// terminal, see SupportsStyles.
+	NoStyles bool
+}
+
+var defaultCompiler = Compiler{}
+
+// Compile parses a format once, so it can be used to format many stamps.
+// The format can use the directives of FormatStamp, directives without a namespace are resolved in TimeNamespace.
+func Compile(format string) (*Formatter, error) {
+	return defaultCompiler.Compile(format)
+}
+
+// Compile parses a format once, so it can be used to format many stamps.
+// If the format contains an invalid directive a *FormatError is returned.
+func (c *Compiler) Compile(format string) (*Formatter, error) {
+	return c.compile(format, true)
+}
+
+// Validate reports the first invalid directive in format as a *FormatError.
+func (c *Compiler) Validate(format string) error {
+	_, err := c.compile(format, true)
+	return err
+}
+
+// mustCompile compiles a format without failing, invalid directives are kept as they are.
+func (c *Compiler) mustCompile(format string) *Formatter {
+	f, _ := c.compile(format, false)
+	return f
+}
+
+func (c *Compiler) compile(format string, strict bool) (*Formatter, error) {
+	return compileScoped(format, strict, scope{
+		namespace: c.defaultNamespace(),
+		location:  c.Location,
+		locale:    c.Locale,
+		noStyles:  c.NoStyles,
+	})
+}
+
+func compileScoped(format string, strict bool, sc scope) (*Formatter, error) {
+	p := parser{
+		scope:  sc,
+		format: []rune(format),
+		strict: strict,
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return &Formatter{segments: p.segments}, nil
+}
+
+func (c *Compiler) defaultNamespace() string {
+	if c.DefaultNamespace == "" {
+		return TimeNamespace
+	}
+	return c.DefaultNamespace
+}
+
+// scope holds what is needed to resolve a directive.
+type scope struct {
+	namespace string
+	// location is the time zone of TimeNamespace, nil means the location of the Stamp.
+	location *time.Location
+	// locale of TimeNamespace, nil means English.
+	locale *Locale
+	// nested is set inside the format of %c, %x or %X.
+	nested bool
+	// noStyles drops the styling directives.
+	noStyles bool
+}
+
+// lookup returns the valueFunc for a directive, or nil if there is no such directive.
+func (sc scope) lookup(directive string) valueFunc {
+	switch sc.namespace {
+	case TimeNamespace:
+		return sc.lookupTime(directive)
+	case ElapsedNamespace:
+		fn, ok := durationDirectives[directive]
+		if !ok {
+			return nil
+		}
+		return func(dst []byte, s Stamp, precision int) []byte {
+			return fn(dst, s.Elapsed(), precision)
+		}
+	case DeltaNamespace:
+		fn, ok := durationDirectives[directive]
+		if !ok {
+			return nil
+		}
+		return func(dst []byte, s Stamp, precision int) []byte {
+			return fn(dst, s.Delta(), precision)
+		}
+	default:
+		return nil
+	}
+}
+
+func (sc scope) lookupTime(directive string) valueFunc {
+	fn, ok := timeDirectives[directive]
+	if !ok {
+		return nil
+	}
+	l := sc.locale
+	if l == nil {
+		l = &English
+	} else if f := l.format(directive); f != "" && !sc.nested {
+		// %c, %x and %X are formatted with the format of the locale
+		nested := sc
+		nested.namespace = TimeNamespace
+		nested.nested = true
+		sub, _ := compileScoped(f, false, nested)
+		return func(dst []byte, s Stamp, _ int) []byte {
+			return sub.AppendFormat(dst, s)
+		}
+	}
+	loc := sc.location
+	if loc != nil {
+		return func(dst []byte, s Stamp, _ int) []byte {
+			return fn(dst, s.Time.In(loc), l)
+		}
+	}
+	return func(dst []byte, s Stamp, _ int) []byte {
+		return fn(dst, s.Time, l)
+	}
+}
+
+// layoutValue returns a valueFunc that formats the time with a Go reference layout.
+func layoutValue(layout string, loc *time.Location) valueFunc {
+	if loc != nil {
+		return func(dst []byte, s Stamp, _ int) []byte {
+			return s.Time.In(loc).AppendFormat(dst, layout)
+		}
+	}
+	return func(dst []byte, s Stamp, _ int) []byte {
+		return s.Time.AppendFormat(dst, layout)
+	}
+}
+
+// valueFunc appends the value of a directive to dst, precision is -1 if it was not specified.
+type valueFunc func(dst []byte, s Stamp, precision int) []byte
+
+type segment struct {
+	literal string
+	value   valueFunc
+	pad     padding
+}
+
+// Formatter is a compiled format, it only evaluates the directives that are used in the format.
+type Formatter struct {
+	segments []segment
+}
+
+// AppendFormat appends the formatted stamp to dst and returns the extended buffer.
+// It does not allocate if dst has enough capacity.
+func (f *Formatter) AppendFormat(dst []byte, s Stamp) []byte {
+	for i := range f.segments {
+		seg := &f.segments[i]
+		if seg.value == nil {
+			dst = append(dst, seg.literal...)
+			continue
+		}
+		start := len(dst)
+		dst = seg.value(dst, s, seg.pad.precision)
+		dst = seg.pad.apply(dst, start)
+	}
+	return dst
+}
+
+// Format returns the formatted stamp.
+func (f *Formatter) Format(s Stamp) string {
+	return string(f.AppendFormat(make([]byte, 0, 64), s))
+}
+
+// FormatFunc returns a FormatFunc that formats the next Stamp of t for every line.
+func (f *Formatter) FormatFunc(t *Timer) FormatFunc {
+	var mu sync.Mutex
+	var buf []byte
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		buf = f.AppendFormat(buf[:0], t.Next())
+		return string(buf)
+	}
+}
+
+// FormatAtFunc returns a FormatAtFunc that formats the Stamp of t for a line that started at the arrival time.
+func (f *Formatter) FormatAtFunc(t *Timer) FormatAtFunc {
+	var mu sync.Mutex
+	var buf []byte
+	return func(arrival time.Time) string {
+		mu.Lock()
+		defer mu.Unlock()
+		buf = f.AppendFormat(buf[:0], t.NextAt(arrival))
+		return string(buf)
+	}
+}
+
+type parser struct {
+	scope    scope
+	format   []rune
+	strict   bool
+	segments []segment
+	literal  []rune
+}
+
+func (p *parser) parse() error {
+	for i := 0; i < len(p.format); {
+		if p.format[i] != '%' {
+			p.literal = append(p.literal, p.format[i])
+			i++
+			continue
+		}
+		n, err := p.parseDirective(i)
+		if err != nil {
+			return err
+		}
+		i += n
+	}
+	p.flushLiteral()
+	return nil
+}
+
+func (p *parser) flushLiteral() {
+	if len(p.literal) == 0 {
+		return
+	}
+	p.segments = append(p.segments, segment{literal: string(p.literal)})
+	p.literal = p.literal[:0]
+}
+
+func (p *parser) addValue(value valueFunc, pad padding) {
+	p.flushLiteral()
+	p.segments = append(p.segments, segment{value: value, pad: pad})
+}
+
+// parseDirective parses the directive at index i and returns the number of consumed runes.
+func (p *parser) parseDirective(i int) (int, error) {
+	if i+1 >= len(p.format) {
+		if p.strict {
+			return 0, p.error(i, i+1, ErrIncompleteDirective)
+		}
+		p.literal = append(p.literal, '%')
+		return 1, nil
+	}
+	switch p.format[i+1] {
+	case '%':
+		p.literal = append(p.literal, '%')
+		return 2, nil
+	case '{':
+		return p.parseQualified(i)
+	}
+
+	rest := p.format[i+1:]
+	seg, n, err := p.parseValue(rest, p.scope)
+	if err != nil {
+		if p.strict {
+			return 0, p.error(i, i+1+max(n, 1), err)
+		}
+		// invalid directives are kept as they are
+		p.literal = append(p.literal, '%')
+		p.literal = append(p.literal, rest[:n]...)
+		return 1 + n, nil
+	}
+	p.addValue(seg.value, seg.pad)
+	return 1 + n, nil
+}
+
+// parseQualified parses a directive in the form %{namespace:directive} or %{namespace@zone:directive}.
+func (p *parser) parseQualified(i int) (int, error) {
+	end := -1
+	for j := i + 2; j < len(p.format); j++ {
+		if p.format[j] == '}' {
+			end = j
+			break
+		}
+	}
+	if end < 0 {
+		if p.strict {
+			return 0, p.error(i, len(p.format), ErrIncompleteDirective)
+		}
+		p.literal = append(p.literal, '%')
+		return 1, nil
+	}
+
+	seg, err := p.parseQualifiedValue(string(p.format[i+2 : end]))
+	if err == nil {
+		if seg.value == nil {
+			// styling directives are literals
+			p.literal = append(p.literal, []rune(seg.literal)...)
+		} else {
+			p.addValue(seg.value, seg.pad)
+		}
+		return end - i + 1, nil
+	}
+
+	if p.strict {
+		return 0, p.error(i, end+1, err)
+	}
+	p.literal = append(p.literal, p.format[i:end+1]...)
+	return end - i + 1, nil
+}
+
+// parseQualifiedValue parses the content of a %{...} directive.
+func (p *parser) parseQualifiedValue(content string) (segment, error) {
+	namespace, directive, ok := strings.Cut(content, ":")
+	if !ok {
+		style, ok := styles[content]
+		if !ok {
+			return segment{}, ErrUnknownNamespace
+		}
+		return p.style(style), nil
+	}
+	if namespace == ForegroundNamespace || namespace == BackgroundNamespace {
+		color, ok := colorSequence(namespace, directive)
+		if !ok {
+			return segment{}, ErrUnknownColor
+		}
+		return p.style(color), nil
+	}
+	sc := p.scope
+	namespace, zone, hasZone := strings.Cut(namespace, "@")
+	isTime := namespace == TimeNamespace || namespace == LayoutNamespace
+	if !isNamespace(namespace) || (hasZone && !isTime) {
+		return segment{}, ErrUnknownNamespace
+	}
+	if hasZone {
+		var err error
+		sc.location, err = time.LoadLocation(zone)
+		if err != nil || zone == "" {
+			return segment{}, ErrUnknownTimeZone
+		}
+	}
+
+	if namespace == LayoutNamespace {
+		if directive == "" {
+			return segment{}, ErrIncompleteDirective
+		}
+		return segment{value: layoutValue(directive, sc.location)}, nil
+	}
+
+	sc.namespace = namespace
+	d := []rune(directive)
+	seg, n, err := p.parseValue(d, sc)
+	if err == nil && n != len(d) {
+		err = ErrUnknownDirective
+	}
+	return seg, err
+}
+
+// style returns the literal segment of a styling directive.
+func (p *parser) style(sequence string) segment {
+	if p.scope.noStyles {
+		return segment{}
+	}
+	return segment{literal: sequence}
+}
+
+// error returns a FormatError for the directive that spans from start to end.
+func (p *parser) error(start, end int, err error) error {
+	return &FormatError{
+		Format:    string(p.format),
+		Directive: string(p.format[start:end]),
+		Pos:       start,
+		Err:       err,
+	}
+}
+
+// parseValue parses an optional padding followed by a directive of the namespace of sc.
+// It returns the number of consumed runes, for an invalid directive the number of runes that make up the
+// directive is returned.
+func (p *parser) parseValue(r []rune, sc scope) (segment, int, error) {
+	// the padding ends as soon as a letter follows a number or a .
+	// %02d
+	//    ^ directive
+	keyPos := -1
+	end := len(r)
+	last := utf8.RuneError
+	for j := 0; j < len(r); j++ {
+		if unicode.IsSpace(r[j]) || r[j] == '%' {
+			end = j
+			break
+		}
+		if (unicode.IsLetter(r[j]) || r[j] == ':') && (unicode.IsNumber(last) || last == '.') {
+			keyPos = j
+			break
+		}
+		last = r[j]
+	}
+	if keyPos == -1 {
+		keyPos = 0
+	}
+
+	// directives start with a letter, optionally preceded by colons (%:z), and can be followed by
+	// letters and numbers
+	letterPos := keyPos
+	for letterPos < end && r[letterPos] == ':' {
+		letterPos++
+	}
+	keyEnd := end
+	for j := letterPos; j < end; j++ {
+		if !unicode.IsLetter(r[j]) && (j == letterPos || !unicode.IsNumber(r[j])) {
+			keyEnd = j
+			break
+		}
+	}
+	if letterPos >= keyEnd {
+		return segment{}, 0, ErrIncompleteDirective
+	}
+
+	// use the longest directive that matches
+	for k := keyEnd; k > keyPos; k-- {
+		value := sc.lookup(string(r[keyPos:k]))
+		if value == nil {
+			continue
+		}
+		pad, err := parsePadding(r[:keyPos])
+		if err != nil {
+			return segment{}, k, fmt.Errorf("%w: %s", ErrInvalidPadding, err)
+		}
+		return segment{value: value, pad: pad}, k, nil
+	}
+	return segment{}, keyEnd, ErrUnknownDirective
+}
+
+// padding describes how a value is padded:
+//
+//	+AB10.2
+//	      ^^ precision is 2 (only for directives that support it)
+//	   ^^ Total value length is 10
+//	 ^^ Fill padding with AB
+//	^ + => pad left  (ABABAHello) (default) (optional)
+//	  - => pad right   (HelloABABA)
+//	  | => pad middle (ABHelloABA)
+type padding struct {
+	direction rune
+	runes     []rune
+	width     int
+	precision int
+}
+
+func parsePadding(prefix []rune) (padding, error) {
+	pad := padding{precision: -1}
+	if len(prefix) == 0 {
+		return pad, nil
+	}
+	i := 0
+	switch prefix[0] {
+	case '+', '-', '|':
+		pad.direction = prefix[0]
+		i = 1
+	}
+	for ; i < len(prefix); i++ {
+		// 0 can be used as padding, so the width starts with any other number or a .
+		if prefix[i] == '0' || (prefix[i] != '.' && !unicode.IsNumber(prefix[i])) {
+			pad.runes = append(pad.runes, prefix[i])
+			continue
+		}
+		width, precision, hasPrecision := strings.Cut(string(prefix[i:]), ".")
+		if hasPrecision {
+			// a . without a value means a precision of 0
+			pad.precision = 0
+			if precision != "" {
+				n, err := strconv.ParseUint(precision, 10, 8)
+				if err != nil {
+					return pad, err
+				}
+				pad.precision = int(n)
+			}
+		}
+		if width == "" {
+			break
+		}
+		n, err := strconv.ParseUint(width, 10, 16)
+		if err != nil {
+			return pad, err
+		}
+		pad.width = int(n)
+		break
+	}
+	return pad, nil
+}
+
+func (pad *padding) rune(i int) rune {
+	if len(pad.runes) == 0 {
+		return ' '
+	}
+	return pad.runes[i%len(pad.runes)]
+}
+
+func (pad *padding) appendFill(dst []byte, n int) []byte {
+	for i := 0; i < n; i++ {
+		dst = utf8.AppendRune(dst, pad.rune(i))
+	}
+	return dst
+}
+
+// insertFill inserts n pad runes at index at.
+func (pad *padding) insertFill(dst []byte, at, n int) []byte {
+	end := len(dst)
+	dst = pad.appendFill(dst, n)
+	// rotate the fill in front of the value
+	reverse(dst[at:end])
+	reverse(dst[end:])
+	reverse(dst[at:])
+	return dst
+}
+
+// apply pads the value that starts at index start of dst.
+func (pad *padding) apply(dst []byte, start int) []byte {
+	if pad.width <= 0 {
+		return dst
+	}
+	fill := pad.width - utf8.RuneCount(dst[start:])
+	if fill <= 0 {
+		return dst
+	}
+	switch pad.direction {
+	case '-':
+		return pad.appendFill(dst, fill)
+	case '|':
+		left := fill / 2
+		dst = pad.insertFill(dst, start, left)
+		return pad.appendFill(dst, fill-left)
+	default:
+		return pad.insertFill(dst, start, fill)
+	}
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// appendInt appends v to dst, zero padded to width digits.
+func appendInt(dst []byte, v, width int) []byte {
+	if v < 0 {
+		dst = append(dst, '-')
+		v = -v
+	}
+	for w, x := 1, v; w < width; w++ {
+		x /= 10
+		if x == 0 {
+			dst = append(dst, '0')
+		}
+	}
+	return strconv.AppendInt(dst, int64(v), 10)
+}
+
+// appendSpaceInt appends v to dst, space padded to width digits.
+func appendSpaceInt(dst []byte, v, width int) []byte {
+	for w, x := 1, v; w < width; w++ {
+		x /= 10
+		if x == 0 {
+			dst = append(dst, ' ')
+		}
+	}
+	return strconv.AppendInt(dst, int64(v), 10)
+}
+
+// appendDuration appends d in the form hours:minutes:seconds.
+func appendDuration(dst []byte, d time.Duration) []byte {
+	if d < 0 {
+		dst = append(dst, '-')
+		d = -d
+	}
+	s := int(d / time.Second)
+	dst = appendInt(dst, s/3600, 2)
+	dst = append(dst, ':')
+	dst = appendInt(dst, s%3600/60, 2)
+	dst = append(dst, ':')
+	return appendInt(dst, s%60, 2)
+}
@@ -0,0 +1,220 @@
+package logtimer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Eun/mapprint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	start := time.Date(2019, 2, 7, 11, 26, 45, 0, time.UTC)
+	s := Stamp{
+		Time:     start.Add(3*time.Minute + 12*time.Second + 4*time.Millisecond),
+		Start:    start,
+		Previous: start.Add(3*time.Minute + 11*time.Second),
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"[%X] ", "[11:29:57] "},
+		{"[%Y-%02m-%02d %02H:%02M:%02S.%06f]", "[2019-02-07 11:29:57.004000]"},
+		{"[%X +%{elapsed:Xf} %{delta:Xn}]", "[11:29:57 +00:03:12.004000 00:00:01.004000000]"},
+		{"%5d|%-5d|%|5d|%_5d|%AB5d", "    7|7    |  7  |____7|ABAB7"},
+		{"%2.1d|%0d|%4y", " 7|7|  19"},
+		{"%{elapsed:010X}|%{time:-3H}|", "0000:03:12|11 |"},
+		{"%·4H|%|ab7H", "··11|ab11aba"},
+		{"%Xfoo %%Q %%", "11:29:57foo %Q %"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			f, err := Compile(test.format)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, f.Format(s))
+			require.Equal(t, "prefix "+test.expected, string(f.AppendFormat([]byte("prefix "), s)))
+		})
+	}
+
+	t.Run("DefaultNamespace", func(t *testing.T) {
+		c := Compiler{DefaultNamespace: DeltaNamespace}
+		f, err := c.Compile("[%Xf %{time:X}] ")
+		require.NoError(t, err)
+		require.Equal(t, "[00:00:01.004000 11:29:57] ", f.Format(s))
+	})
+
+	t.Run("Location", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+
+		c := Compiler{Location: tokyo}
+		f, err := c.Compile("%X %:z|%{time@UTC:X} %{time@UTC:Z}|%{time@Europe/Berlin:X} %{time@Europe/Berlin:Z}|%{time:H}")
+		require.NoError(t, err)
+		require.Equal(t, "20:29:57 +09:00|11:29:57 UTC|12:29:57 CET|20", f.Format(s))
+
+		f, err = Compile("%X|%{time@Asia/Tokyo:X}")
+		require.NoError(t, err)
+		require.Equal(t, "11:29:57|20:29:57", f.Format(s))
+	})
+
+	t.Run("Invalid Directives", func(t *testing.T) {
+		tests := []struct {
+			format    string
+			directive string
+			pos       int
+			err       error
+			lenient   string
+		}{
+			{"[%Q] ", "%Q", 1, ErrUnknownDirective, "[%Q] "},
+			{"[%X] %3Q", "%3Q", 5, ErrUnknownDirective, "[11:29:57] %3Q"},
+			{"[%X] %", "%", 5, ErrIncompleteDirective, "[11:29:57] %"},
+			{"50% done", "% ", 2, ErrIncompleteDirective, "50% done"},
+			{"[%99999999X]", "%99999999X", 1, ErrInvalidPadding, "[%99999999X]"},
+			{"%X %{elapsed:Xfoo}", "%{elapsed:Xfoo}", 3, ErrUnknownDirective, "11:29:57 %{elapsed:Xfoo}"},
+			{"%X %{elapsed:Q}", "%{elapsed:Q}", 3, ErrUnknownDirective, "11:29:57 %{elapsed:Q}"},
+			{"%{unknown:X}", "%{unknown:X}", 0, ErrUnknownNamespace, "%{unknown:X}"},
+			{"%{X}", "%{X}", 0, ErrUnknownNamespace, "%{X}"},
+			{"%{time@Mars/Olympus:X}", "%{time@Mars/Olympus:X}", 0, ErrUnknownTimeZone, "%{time@Mars/Olympus:X}"},
+			{"%{time@:X}", "%{time@:X}", 0, ErrUnknownTimeZone, "%{time@:X}"},
+			{"%{elapsed@UTC:X}", "%{elapsed@UTC:X}", 0, ErrUnknownNamespace, "%{elapsed@UTC:X}"},
+			{"äö %{time:X", "%{time:X", 3, ErrIncompleteDirective, "äö %{time:X"},
+		}
+		for _, test := range tests {
+			t.Run(test.format, func(t *testing.T) {
+				_, err := Compile(test.format)
+				var formatError *FormatError
+				require.ErrorAs(t, err, &formatError)
+				require.Equal(t, test.format, formatError.Format)
+				require.Equal(t, test.directive, formatError.Directive)
+				require.Equal(t, test.pos, formatError.Pos)
+				require.ErrorIs(t, err, test.err)
+				require.Equal(t, test.lenient, FormatTime(s.Time, test.format))
+			})
+		}
+
+		for format, caret := range map[string]string{
+			"[%Q] ":      " ^",
+			"\t[%Q]":     "\t ^",
+			"日本 %Q":      "     ^",
+			"e\u0301 %Q": "  ^",
+		} {
+			_, err := Compile(format)
+			var formatError *FormatError
+			require.ErrorAs(t, err, &formatError)
+			require.Equal(t, caret, formatError.Caret(), format)
+		}
+
+		require.EqualError(t, ValidateTime("[%Q] "), `directive "%Q" at position 1: unknown directive`)
+		require.NoError(t, ValidateTime("[%X] "))
+		require.EqualError(t, ValidateDuration("[%Y] "), `directive "%Y" at position 1: unknown directive`)
+		require.NoError(t, ValidateDuration("[%X] "))
+	})
+
+	t.Run("No Allocations", func(t *testing.T) {
+		f, err := Compile("[%a, %02d %b %Y %02H:%02M:%02S.%06f %z %Z %{elapsed:Xn} %{delta:|12X}] ")
+		require.NoError(t, err)
+		buf := make([]byte, 0, 256)
+		allocs := testing.AllocsPerRun(100, func() {
+			buf = f.AppendFormat(buf[:0], s)
+		})
+		require.Zero(t, allocs)
+	})
+}
+
+func TestFormatterFormatFunc(t *testing.T) {
+	f, err := (&Compiler{DefaultNamespace: ElapsedNamespace}).Compile("%X")
+	require.NoError(t, err)
+	require.Equal(t, "00:00:00", f.FormatFunc(NewTimer())())
+}
+
+func TestFormatterFormatAtFunc(t *testing.T) {
+	f, err := (&Compiler{DefaultNamespace: ElapsedNamespace}).Compile("%X %{delta:X}")
+	require.NoError(t, err)
+	timer := NewTimer()
+	// a time before the start stamps the start without advancing the timer
+	start := timer.NextAt(time.Time{}).Time
+	formatAt := f.FormatAtFunc(timer)
+	require.Equal(t, "00:01:00 00:01:00", formatAt(start.Add(time.Minute)))
+	require.Equal(t, "00:11:00 00:10:00", formatAt(start.Add(11*time.Minute)))
+}
+
+// mapprintFormatTime is the previous implementation of FormatTime, it is kept to compare the performance.
+func mapprintFormatTime(t time.Time, f string) string {
+	return mapprint.Sprintf(f, map[string]interface{}{
+		"a": English.ShortDayNames[t.Weekday()],
+		"A": English.LongDayNames[t.Weekday()],
+		"w": t.Weekday,
+		"d": t.Day,
+		"b": English.ShortMonthNames[t.Month()-1],
+		"B": English.LongMonthNames[t.Month()-1],
+		"m": t.Month,
+		"y": t.Year() % 100,
+		"Y": t.Year,
+		"H": t.Hour,
+		"I": func() int {
+			return hour12(t)
+		},
+		"p": func() string {
+			if t.Hour() < 12 {
+				return "AM"
+			}
+			return "PM"
+		},
+		"M": t.Minute,
+		"S": t.Second,
+		"f": t.Nanosecond() / 1000,
+		"z": t.Format("-0700"),
+		"Z": t.Format("MST"),
+		"j": t.YearDay(),
+		"U": weekNumber(t, 'U'),
+		"W": weekNumber(t, 'W'),
+		"c": t.Format("Mon Jan 2 15:04:05 2006"),
+		"x": fmt.Sprintf("%02d/%02d/%02d", t.Month(), t.Day(), t.Year()%100),
+		"X": fmt.Sprintf("%02d:%02d:%02d", t.Hour(), t.Minute(), t.Second()),
+	})
+}
+
+func BenchmarkFormat(b *testing.B) {
+	const format = "[%a, %d %b %Y %02H:%02M:%02S %Z] "
+	now := time.Date(2019, 2, 7, 11, 26, 45, 0, time.UTC)
+
+	b.Run("mapprint", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = mapprintFormatTime(now, format)
+		}
+	})
+
+	b.Run("FormatTime", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = FormatTime(now, format)
+		}
+	})
+
+	b.Run("Compiled", func(b *testing.B) {
+		f, err := Compile(format)
+		require.NoError(b, err)
+		s := Stamp{Time: now}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = f.Format(s)
+		}
+	})
+
+	b.Run("CompiledAppend", func(b *testing.B) {
+		f, err := Compile(format)
+		require.NoError(b, err)
+		s := Stamp{Time: now}
+		buf := make([]byte, 0, 64)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			buf = f.AppendFormat(buf[:0], s)
+		}
+	})
+}
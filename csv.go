@@ -0,0 +1,138 @@
+package logtimer
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+// HeaderEncoder is a RecordEncoder whose records are preceded by a header, e.g. the column names of CSVEncoder.
+type HeaderEncoder interface {
+	RecordEncoder
+	// AppendHeader appends the header to dst.
+	AppendHeader(dst []byte) []byte
+}
+
+// CSVEncoder encodes a Record as a row of comma separated values (RFC 4180) with the columns
+//
+//	ts,elapsed_ns,delta_ns,line,stream,seq
+//
+// with SeparateANSI the original line follows line in the column raw.
+// Fields that contain the separator, quotes or line breaks are quoted.
+type CSVEncoder struct {
+	// Location is the time zone of ts, if it is nil the location of the Stamp is used.
+	Location *time.Location
+	ANSI     ANSI
+	// Comma is the field separator, if it is 0 a comma is used.
+	Comma byte
+}
+
+// AppendHeader appends the row of column names to dst.
+func (e CSVEncoder) AppendHeader(dst []byte) []byte {
+	return appendColumnNames(dst, e.ANSI, e.comma())
+}
+
+// AppendRecord appends the row of r to dst.
+func (e CSVEncoder) AppendRecord(dst []byte, r Record) []byte {
+	comma := e.comma()
+	return appendColumns(dst, r, e.Location, e.ANSI, comma, func(dst, field []byte) []byte {
+		return appendCSVField(dst, field, comma)
+	})
+}
+
+func (e CSVEncoder) comma() byte {
+	if e.Comma == 0 {
+		return ','
+	}
+	return e.Comma
+}
+
+// appendCSVField appends field to dst, quoted if it contains comma, a quote, a line break or starts with a space.
+func appendCSVField(dst, field []byte, comma byte) []byte {
+	if !bytes.ContainsAny(field, "\"\r\n") && bytes.IndexByte(field, comma) < 0 &&
+		(len(field) == 0 || field[0] != ' ') {
+		return append(dst, field...)
+	}
+	dst = append(dst, '"')
+	for _, b := range field {
+		if b == '"' {
+			dst = append(dst, '"')
+		}
+		dst = append(dst, b)
+	}
+	return append(dst, '"')
+}
+
+// TSVEncoder encodes a Record as a row of tab separated values with the columns of CSVEncoder.
+// Tabs, line breaks and backslashes in a field are escaped as \t, \n, \r and \\.
+type TSVEncoder struct {
+	// Location is the time zone of ts, if it is nil the location of the Stamp is used.
+	Location *time.Location
+	ANSI     ANSI
+}
+
+// AppendHeader appends the row of column names to dst.
+func (e TSVEncoder) AppendHeader(dst []byte) []byte {
+	return appendColumnNames(dst, e.ANSI, '\t')
+}
+
+// AppendRecord appends the row of r to dst.
+func (e TSVEncoder) AppendRecord(dst []byte, r Record) []byte {
+	return appendColumns(dst, r, e.Location, e.ANSI, '\t', appendTSVField)
+}
+
+// appendTSVField appends field to dst with its tabs, line breaks and backslashes escaped.
+func appendTSVField(dst, field []byte) []byte {
+	for _, b := range field {
+		switch b {
+		case '\t':
+			dst = append(dst, `\t`...)
+		case '\n':
+			dst = append(dst, `\n`...)
+		case '\r':
+			dst = append(dst, `\r`...)
+		case '\\':
+			dst = append(dst, `\\`...)
+		default:
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
+
+// appendColumnNames appends the names of the columns of CSVEncoder and TSVEncoder to dst.
+func appendColumnNames(dst []byte, ansi ANSI, sep byte) []byte {
+	names := []string{"ts", "elapsed_ns", "delta_ns", "line", "stream", "seq"}
+	if ansi == SeparateANSI {
+		names = []string{"ts", "elapsed_ns", "delta_ns", "line", "raw", "stream", "seq"}
+	}
+	for i, name := range names {
+		if i > 0 {
+			dst = append(dst, sep)
+		}
+		dst = append(dst, name...)
+	}
+	return append(dst, '\n')
+}
+
+// appendColumns appends the columns of r to dst, every field is appended with appendField.
+func appendColumns(dst []byte, r Record, loc *time.Location, ansi ANSI, sep byte,
+	appendField func(dst, field []byte) []byte) []byte {
+	var scratch [64]byte
+	dst = appendField(dst, recordTime(r, loc).AppendFormat(scratch[:0], time.RFC3339Nano))
+	dst = append(dst, sep)
+	dst = strconv.AppendInt(dst, int64(r.Elapsed()), 10)
+	dst = append(dst, sep)
+	dst = strconv.AppendInt(dst, int64(r.Delta()), 10)
+	dst = append(dst, sep)
+	dst = appendField(dst, ansi.line(r))
+	if ansi == SeparateANSI {
+		dst = append(dst, sep)
+		dst = appendField(dst, r.Line)
+	}
+	dst = append(dst, sep)
+	dst = appendField(dst, []byte(r.Stream))
+	dst = append(dst, sep)
+	dst = strconv.AppendInt(dst, int64(r.Seq), 10)
+	return append(dst, '\n')
+}
@@ -0,0 +1,65 @@
+package logtimer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord(line string) Record {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+	return Record{
+		Stamp: Stamp{
+			Time:     start.Add(1500 * time.Millisecond),
+			Start:    start,
+			Previous: start.Add(time.Second),
+			Seq:      2,
+		},
+		Line:   []byte(line),
+		Stream: "stdout",
+	}
+}
+
+func TestCSVEncoder(t *testing.T) {
+	t.Run("Quoting", func(t *testing.T) {
+		var e CSVEncoder
+		require.Equal(t, "ts,elapsed_ns,delta_ns,line,stream,seq\n", string(e.AppendHeader(nil)))
+		require.Equal(t, "2019-02-07T11:26:46.5Z,1500000000,500000000,Hello,stdout,2\n",
+			string(e.AppendRecord(nil, testRecord("Hello"))))
+		require.Equal(t, "2019-02-07T11:26:46.5Z,1500000000,500000000,\"say \"\"hi\"\", bye\",stdout,2\n",
+			string(e.AppendRecord(nil, testRecord(`say "hi", bye`))))
+	})
+
+	t.Run("Round Trip", func(t *testing.T) {
+		lines := []string{"", " leading space", "a,b", "multi\nline\r\n", "\x1b[31mred\x1b[0m", "tab\tseparated", `"`}
+		for _, comma := range []byte{',', ';', '\t'} {
+			e := CSVEncoder{ANSI: SeparateANSI, Comma: comma}
+			buf := e.AppendHeader(nil)
+			for _, line := range lines {
+				buf = e.AppendRecord(buf, testRecord(line))
+			}
+			r := csv.NewReader(bytes.NewReader(buf))
+			r.Comma = rune(comma)
+			rows, err := r.ReadAll()
+			require.NoError(t, err)
+			require.Len(t, rows, len(lines)+1)
+			require.Equal(t, []string{"ts", "elapsed_ns", "delta_ns", "line", "raw", "stream", "seq"}, rows[0])
+			for i, line := range lines {
+				// encoding/csv reads \r\n in quoted fields as \n
+				expected := string(bytes.ReplaceAll([]byte(line), []byte("\r\n"), []byte("\n")))
+				require.Equal(t, expected, rows[i+1][4])
+				require.Equal(t, string(stripEscapes(nil, []byte(expected))), rows[i+1][3])
+			}
+		}
+	})
+}
+
+func TestTSVEncoder(t *testing.T) {
+	e := TSVEncoder{ANSI: StripANSI}
+	require.Equal(t, "ts\telapsed_ns\tdelta_ns\tline\tstream\tseq\n", string(e.AppendHeader(nil)))
+	require.Equal(t, "2019-02-07T11:26:46.5Z\t1500000000\t500000000\ta\\tb\\nc\\r\\\\d \"e\"\tstdout\t2\n",
+		string(e.AppendRecord(nil, testRecord("a\tb\nc\r\\d \x1b[1m\"e\""))))
+}
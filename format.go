@@ -1,65 +1,10 @@
 package logtimer
 
 import (
-	"fmt"
-	"math"
+	"strconv"
 	"time"
-
-	"github.com/Eun/mapprint"
 )
 
-var longDayNames = []string{
-	"Sunday",
-	"Monday",
-	"Tuesday",
-	"Wednesday",
-	"Thursday",
-	"Friday",
-	"Saturday",
-}
-
-var shortDayNames = []string{
-	"Sun",
-	"Mon",
-	"Tue",
-	"Wed",
-	"Thu",
-	"Fri",
-	"Sat",
-}
-
-var shortMonthNames = []string{
-	"---",
-	"Jan",
-	"Feb",
-	"Mar",
-	"Apr",
-	"May",
-	"Jun",
-	"Jul",
-	"Aug",
-	"Sep",
-	"Oct",
-	"Nov",
-	"Dec",
-}
-
-var longMonthNames = []string{
-	"---",
-	"January",
-	"February",
-	"March",
-	"April",
-	"May",
-	"June",
-	"July",
-	"August",
-	"September",
-	"October",
-	"November",
-	"December",
-}
-
 func weekNumber(t time.Time, char int) int {
 	weekday := int(t.Weekday())
 
@@ -79,19 +24,32 @@ func weekNumber(t time.Time, char int) int {
 // %a    Weekday as locale’s abbreviated name.                             (Sun, Mon, ..., Sat)
 // %A    Weekday as locale’s full name.                                    (Sunday, Monday, ..., Saturday)
 // %w    Weekday as a decimal number, where 0 is Sunday and 6 is Saturday  (0, 1, ..., 6)
+// %u    Weekday as a decimal number, where 1 is Monday and 7 is Sunday    (1, 2, ..., 7)
 // %d    Day of the month as a decimal number.                             (1, 2, ..., 31)
+// %e    Day of the month as a space padded decimal number.                ( 1,  2, ..., 31)
 // %b    Month as locale’s abbreviated name.                               (Jan, Feb, ..., Dec)
+// %h    Same as %b.                                                       (Jan, Feb, ..., Dec)
 // %B    Month as locale’s full name.                                      (January, February, ..., December)
 // %m    Month as a decimal number.                                        (1, 2, ..., 12)
 // %y    Year without century as a decimal number.                         (0, 1, ..., 99)
 // %Y    Year with century as a decimal number.                            (1970, 1988, 2001, 2013)
+// %C    Century as a zero padded decimal number.                          (19, 20)
+// %G    ISO 8601 week-based year with century as a decimal number.        (1970, 1988, 2001, 2013)
+// %g    ISO 8601 week-based year without century as a zero padded decimal number. (00, 01, ..., 99)
 // %H    Hour (24-hour clock) as a decimal number.                          (0, 1, ..., 23)
+// %k    Hour (24-hour clock) as a space padded decimal number.            ( 0,  1, ..., 23)
 // %I    Hour (12-hour clock) as a decimal number.                          (1, 2, ..., 12)
+// %l    Hour (12-hour clock) as a space padded decimal number.            ( 1,  2, ..., 12)
 // %p    Meridian indicator.                                               (AM, PM)
+// %P    Meridian indicator in lower case.                                 (am, pm)
 // %M    Minute as a decimal number.                                       (0, 1, ..., 59)
 // %S    Second as a decimal number.                                       (0, 1, ..., 59)
 // %f    Microsecond as a decimal number.                                  (0, 1, ..., 999999)
+// %L    Millisecond as a zero padded decimal number.                      (000, 001, ..., 999)
+// %N    Nanosecond as a zero padded decimal number.                       (000000000, ..., 999999999)
+// %s    Seconds since the Unix epoch.                                     (1549538805)
 // %z    UTC offset in the form +HHMM or -HHMM                             (+0000)
+// %:z   UTC offset in the form +HH:MM or -HH:MM                           (+00:00)
 // %Z    Time zone name                                                    (UTC)
 // %j    Day of the year as a decimal number                               (1, 2, ..., 366)
 // %U    Week number of the year (Sunday as the first day of the week) as a decimal number. All days in a new year preceding the first Sunday are considered to be in week 0.
@@ -102,72 +60,415 @@ func weekNumber(t time.Time, char int) int {
 //
 //	(0, 1, ..., 53)
 //
+// %V    ISO 8601 week number of the year as a zero padded decimal number. (01, 02, ..., 53)
 // %c    Date and time representation.                                     (Tue Aug 16 21:30:00 1988)
 // %x    Date representation.                                              (08/16/88)
 // %X    Time representation.                                              (21:30:00)
+// %D    Same as %m/%d/%y with zero padding.                               (08/16/88)
+// %F    Same as %Y-%m-%d with zero padding.                               (1988-08-16)
+// %T    Same as %H:%M:%S with zero padding.                               (21:30:00)
+// %R    Same as %H:%M with zero padding.                                  (21:30)
+// %n    A newline character.
+// %t    A tab character.
 // %%    A literal '%' character.                                          (%)
+//
+// FormatTime uses English names, use a Compiler with a Locale for other languages.
+// Invalid directives are printed as they are, use ValidateTime to check a format.
 func FormatTime(t time.Time, f string) string {
-	return mapprint.Sprintf(f, map[string]interface{}{
-		"a": shortDayNames[t.Weekday()],
-		"A": longDayNames[t.Weekday()],
-		"w": t.Weekday,
-		"d": t.Day,
-		"b": shortMonthNames[t.Month()],
-		"B": longMonthNames[t.Month()],
-		"m": t.Month,
-		"y": t.Year() % 100,
-		"Y": t.Year,
-		"H": t.Hour,
-		"I": func() int {
-			if t.Hour() == 0 {
-				return 12
-			} else if t.Hour() > 12 {
-				return t.Hour() - 12
-			} else {
-				return t.Hour()
-			}
-		},
-		"p": func() string {
-			if t.Hour() < 12 {
-				return "AM"
-			}
-			return "PM"
-		},
-		"M": t.Minute,
-		"S": t.Second,
-		"f": t.Nanosecond() / 1000,
-		"z": t.Format("-0700"),
-		"Z": t.Format("MST"),
-		"j": t.YearDay(),
-		"U": weekNumber(t, 'U'),
-		"W": weekNumber(t, 'W'),
-		"c": t.Format("Mon Jan 2 15:04:05 2006"),
-		"x": fmt.Sprintf("%02d/%02d/%02d", t.Month(), t.Day(), t.Year()%100),
-		"X": fmt.Sprintf("%02d:%02d:%02d", t.Hour(), t.Minute(), t.Second()),
-	})
+	c := Compiler{DefaultNamespace: TimeNamespace}
+	return c.mustCompile(f).Format(Stamp{Time: t})
+}
+
+// ValidateTime reports the first invalid directive in a format for FormatTime as a *FormatError.
+func ValidateTime(f string) error {
+	c := Compiler{DefaultNamespace: TimeNamespace}
+	return c.Validate(f)
+}
+
+// timeDirectives format a time, the locale is never nil.
+var timeDirectives = map[string]func(dst []byte, t time.Time, l *Locale) []byte{
+	"a": func(dst []byte, t time.Time, l *Locale) []byte {
+		return append(dst, l.ShortDayNames[t.Weekday()]...)
+	},
+	"A": func(dst []byte, t time.Time, l *Locale) []byte {
+		return append(dst, l.LongDayNames[t.Weekday()]...)
+	},
+	"w": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, int(t.Weekday()), 0)
+	},
+	"d": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Day(), 0)
+	},
+	"b": func(dst []byte, t time.Time, l *Locale) []byte {
+		return append(dst, l.ShortMonthNames[t.Month()-1]...)
+	},
+	"B": func(dst []byte, t time.Time, l *Locale) []byte {
+		return append(dst, l.LongMonthNames[t.Month()-1]...)
+	},
+	"m": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, int(t.Month()), 0)
+	},
+	"y": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Year()%100, 0)
+	},
+	"Y": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Year(), 0)
+	},
+	"H": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Hour(), 0)
+	},
+	"I": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, hour12(t), 0)
+	},
+	"p": func(dst []byte, t time.Time, l *Locale) []byte {
+		if t.Hour() < 12 {
+			return append(dst, l.AM...)
+		}
+		return append(dst, l.PM...)
+	},
+	"M": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Minute(), 0)
+	},
+	"S": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Second(), 0)
+	},
+	"f": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Nanosecond()/1000, 0)
+	},
+	"z": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return t.AppendFormat(dst, "-0700")
+	},
+	"Z": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return t.AppendFormat(dst, "MST")
+	},
+	"j": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.YearDay(), 0)
+	},
+	"U": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, weekNumber(t, 'U'), 0)
+	},
+	"W": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, weekNumber(t, 'W'), 0)
+	},
+	"c": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return t.AppendFormat(dst, "Mon Jan 2 15:04:05 2006")
+	},
+	"x": func(dst []byte, t time.Time, _ *Locale) []byte {
+		dst = appendInt(dst, int(t.Month()), 2)
+		dst = append(dst, '/')
+		dst = appendInt(dst, t.Day(), 2)
+		dst = append(dst, '/')
+		return appendInt(dst, t.Year()%100, 2)
+	},
+	"X": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendClock(dst, t)
+	},
+	"u": func(dst []byte, t time.Time, _ *Locale) []byte {
+		if t.Weekday() == time.Sunday {
+			return append(dst, '7')
+		}
+		return appendInt(dst, int(t.Weekday()), 0)
+	},
+	"e": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendSpaceInt(dst, t.Day(), 2)
+	},
+	"h": func(dst []byte, t time.Time, l *Locale) []byte {
+		return append(dst, l.ShortMonthNames[t.Month()-1]...)
+	},
+	"C": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Year()/100, 2)
+	},
+	"G": func(dst []byte, t time.Time, _ *Locale) []byte {
+		year, _ := t.ISOWeek()
+		return appendInt(dst, year, 0)
+	},
+	"g": func(dst []byte, t time.Time, _ *Locale) []byte {
+		year, _ := t.ISOWeek()
+		return appendInt(dst, year%100, 2)
+	},
+	"V": func(dst []byte, t time.Time, _ *Locale) []byte {
+		_, week := t.ISOWeek()
+		return appendInt(dst, week, 2)
+	},
+	"k": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendSpaceInt(dst, t.Hour(), 2)
+	},
+	"l": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendSpaceInt(dst, hour12(t), 2)
+	},
+	"P": func(dst []byte, t time.Time, l *Locale) []byte {
+		if t.Hour() < 12 {
+			return appendLower(dst, l.AM)
+		}
+		return appendLower(dst, l.PM)
+	},
+	"L": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Nanosecond()/int(time.Millisecond), 3)
+	},
+	"N": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendInt(dst, t.Nanosecond(), 9)
+	},
+	"s": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return strconv.AppendInt(dst, t.Unix(), 10)
+	},
+	":z": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return t.AppendFormat(dst, "-07:00")
+	},
+	"D": func(dst []byte, t time.Time, _ *Locale) []byte {
+		dst = appendInt(dst, int(t.Month()), 2)
+		dst = append(dst, '/')
+		dst = appendInt(dst, t.Day(), 2)
+		dst = append(dst, '/')
+		return appendInt(dst, t.Year()%100, 2)
+	},
+	"F": func(dst []byte, t time.Time, _ *Locale) []byte {
+		dst = appendInt(dst, t.Year(), 4)
+		dst = append(dst, '-')
+		dst = appendInt(dst, int(t.Month()), 2)
+		dst = append(dst, '-')
+		return appendInt(dst, t.Day(), 2)
+	},
+	"T": func(dst []byte, t time.Time, _ *Locale) []byte {
+		return appendClock(dst, t)
+	},
+	"R": func(dst []byte, t time.Time, _ *Locale) []byte {
+		dst = appendInt(dst, t.Hour(), 2)
+		dst = append(dst, ':')
+		return appendInt(dst, t.Minute(), 2)
+	},
+	"n": func(dst []byte, _ time.Time, _ *Locale) []byte {
+		return append(dst, '\n')
+	},
+	"t": func(dst []byte, _ time.Time, _ *Locale) []byte {
+		return append(dst, '\t')
+	},
+}
+
+// appendClock appends the time in the form HH:MM:SS.
+func appendClock(dst []byte, t time.Time) []byte {
+	dst = appendInt(dst, t.Hour(), 2)
+	dst = append(dst, ':')
+	dst = appendInt(dst, t.Minute(), 2)
+	dst = append(dst, ':')
+	return appendInt(dst, t.Second(), 2)
+}
+
+// appendLower appends s with its ASCII letters in lower case.
+func appendLower(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+func hour12(t time.Time) int {
+	if t.Hour() == 0 {
+		return 12
+	} else if t.Hour() > 12 {
+		return t.Hour() - 12
+	}
+	return t.Hour()
 }
 
 // FormatDuration formats a duration, use the follwing format:
 // %X    Total Time elapsed.                                              (85:30:04)
 // %Xf   Total Time with Microseconds elapsed.                            (85:30:04.999999)
 // %Xn   Total Time with Nanoseconds elapsed.                             (85:30:04.999999999)
+// %D    Days elapsed.                                                    (0, 1, ..., 3)
+// %H    Hours of the day elapsed.                                        (0, 1, ..., 23)
+// %M    Minutes of the hour elapsed.                                     (0, 1, ..., 59)
+// %S    Seconds of the minute elapsed.                                   (0, 1, ..., 59)
+// %L    Milliseconds of the second elapsed as a zero padded number.      (000, 001, ..., 999)
+// %f    Microseconds of the second elapsed as a zero padded number.      (000000, 000001, ..., 999999)
+// %N    Nanoseconds of the second elapsed as a zero padded number.       (000000000, ..., 999999999)
+// %s    Total seconds elapsed as a decimal number, use %.3s to set the precision. (307804.999999999)
+// %R    Human readable time elapsed, use %.3R to set the precision of the seconds (default 1).
+//
+//	(3d13h30m04.9s, 1h02m03.4s, 3.4s)
+//
+// %A    Compact time elapsed, scales automatically.                      (850ms, 12.3s, 4m05s, 2h05m, 3d13h)
 // %%    A literal '%' character.                                         (%)
+//
+// The components %D, %H, %M, %S, %L, %f and %N are calculated from the absolute duration.
+// Invalid directives are printed as they are, use ValidateDuration to check a format.
 func FormatDuration(d time.Duration, f string) string {
-	return mapprint.Sprintf(f, map[string]interface{}{
-		"X": func() string {
-			s := d.Seconds()
-			hours := int(math.Floor(s / 3600))
-			//nolint: staticcheck // calling math.Floor on a converted integer is pointless (staticcheck)
-			minutes := int(math.Floor(float64(int(s) % 3600 / 60)))
-			//nolint: staticcheck // calling math.Floor on a converted integer is pointless (staticcheck)
-			seconds := int(math.Floor(float64(int(s) % 3600 % 60)))
-			return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
-		},
-		"Xf": func() string {
-			return FormatDuration(d, "%X") + fmt.Sprintf(".%06d", d%time.Second/time.Microsecond)
-		},
-		"Xn": func() string {
-			return FormatDuration(d, "%X") + fmt.Sprintf(".%09d", d%time.Second)
-		},
-	})
+	c := Compiler{DefaultNamespace: ElapsedNamespace}
+	return c.mustCompile(f).Format(Stamp{Time: time.Time{}.Add(d)})
+}
+
+// ValidateDuration reports the first invalid directive in a format for FormatDuration as a *FormatError.
+func ValidateDuration(f string) error {
+	c := Compiler{DefaultNamespace: ElapsedNamespace}
+	return c.Validate(f)
+}
+
+const day = 24 * time.Hour
+
+var durationDirectives = map[string]func(dst []byte, d time.Duration, precision int) []byte{
+	"X": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendDuration(dst, d)
+	},
+	"Xf": func(dst []byte, d time.Duration, _ int) []byte {
+		dst = appendDuration(dst, d)
+		dst = append(dst, '.')
+		return appendInt(dst, int(absDuration(d)%time.Second/time.Microsecond), 6)
+	},
+	"Xn": func(dst []byte, d time.Duration, _ int) []byte {
+		dst = appendDuration(dst, d)
+		dst = append(dst, '.')
+		return appendInt(dst, int(absDuration(d)%time.Second), 9)
+	},
+	"D": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendInt(dst, int(absDuration(d)/day), 0)
+	},
+	"H": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendInt(dst, int(absDuration(d)%day/time.Hour), 0)
+	},
+	"M": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendInt(dst, int(absDuration(d)%time.Hour/time.Minute), 0)
+	},
+	"S": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendInt(dst, int(absDuration(d)%time.Minute/time.Second), 0)
+	},
+	"L": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendInt(dst, int(absDuration(d)%time.Second/time.Millisecond), 3)
+	},
+	"f": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendInt(dst, int(absDuration(d)%time.Second/time.Microsecond), 6)
+	},
+	"N": func(dst []byte, d time.Duration, _ int) []byte {
+		return appendInt(dst, int(absDuration(d)%time.Second), 9)
+	},
+	"s": func(dst []byte, d time.Duration, precision int) []byte {
+		return strconv.AppendFloat(dst, d.Seconds(), 'f', precision, 64)
+	},
+	"R": appendReadableDuration,
+	"A": appendCompactDuration,
+}
+
+// appendReadableDuration appends d in the form 3d13h30m04.9s, the seconds are truncated to precision digits.
+func appendReadableDuration(dst []byte, d time.Duration, precision int) []byte {
+	if precision < 0 {
+		precision = 1
+	}
+	if d < 0 {
+		dst = append(dst, '-')
+		d = -d
+	}
+	width := 0
+	if d >= day {
+		dst = appendInt(dst, int(d/day), 0)
+		dst = append(dst, 'd')
+		width = 2
+	}
+	if d >= time.Hour {
+		dst = appendInt(dst, int(d%day/time.Hour), width)
+		dst = append(dst, 'h')
+		width = 2
+	}
+	if d >= time.Minute {
+		dst = appendInt(dst, int(d%time.Hour/time.Minute), width)
+		dst = append(dst, 'm')
+		width = 2
+	}
+	dst = appendInt(dst, int(d%time.Minute/time.Second), width)
+	dst = appendFraction(dst, d%time.Second, precision)
+	return append(dst, 's')
+}
+
+// appendCompactDuration appends d with the two most significant units, e.g. 850ms, 12.3s, 4m05s, 2h05m or 3d13h.
+func appendCompactDuration(dst []byte, d time.Duration, _ int) []byte {
+	if d < 0 {
+		dst = append(dst, '-')
+		d = -d
+	}
+	switch {
+	case d < time.Microsecond:
+		return append(appendInt(dst, int(d), 0), "ns"...)
+	case d < time.Millisecond:
+		return append(appendInt(dst, int(d/time.Microsecond), 0), "µs"...)
+	case d < time.Second:
+		return append(appendInt(dst, int(d/time.Millisecond), 0), "ms"...)
+	case d < time.Minute:
+		dst = appendInt(dst, int(d/time.Second), 0)
+		dst = appendFraction(dst, d%time.Second, 1)
+		return append(dst, 's')
+	case d < time.Hour:
+		dst = append(appendInt(dst, int(d/time.Minute), 0), 'm')
+		return append(appendInt(dst, int(d%time.Minute/time.Second), 2), 's')
+	case d < day:
+		dst = append(appendInt(dst, int(d/time.Hour), 0), 'h')
+		return append(appendInt(dst, int(d%time.Hour/time.Minute), 2), 'm')
+	default:
+		dst = append(appendInt(dst, int(d/day), 0), 'd')
+		return append(appendInt(dst, int(d%day/time.Hour), 2), 'h')
+	}
+}
+
+// appendFraction appends the fraction of a second truncated to precision digits, including the decimal point.
+func appendFraction(dst []byte, d time.Duration, precision int) []byte {
+	if precision <= 0 {
+		return dst
+	}
+	if precision > 9 {
+		precision = 9
+	}
+	dst = append(dst, '.')
+	div := time.Duration(1)
+	for i := precision; i < 9; i++ {
+		div *= 10
+	}
+	return appendInt(dst, int(d/div), precision)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// Namespaces that can be used to qualify a directive in FormatStamp.
+const (
+	// TimeNamespace contains the directives of FormatTime, they format the time the line started.
+	TimeNamespace = "time"
+	// ElapsedNamespace contains the directives of FormatDuration, they format the time elapsed since the start.
+	ElapsedNamespace = "elapsed"
+	// DeltaNamespace contains the directives of FormatDuration, they format the time elapsed since the previous
+	// line started.
+	DeltaNamespace = "delta"
+	// LayoutNamespace formats the time the line started with a Go reference layout, see time.Layout.
+	LayoutNamespace = "layout"
+)
+
+// FormatStamp formats a Stamp, it allows to mix the directives of FormatTime and FormatDuration by qualifying them
+// with a namespace:
+// %{time:X}       Time representation of the line start.                  (21:30:00)
+// %{elapsed:Xf}   Total Time with Microseconds elapsed since the start.    (85:30:04.999999)
+// %{delta:Xf}     Total Time with Microseconds elapsed since the previous line started. (00:00:01.000012)
+// %{time@UTC:X}   Time representation of the line start in an IANA time zone. (20:30:00)
+// %{layout:2006-01-02T15:04:05Z07:00}  The line start formatted with a Go reference layout. (1988-08-16T21:30:00+01:00)
+// %{layout@UTC:15:04:05}  The line start formatted with a Go reference layout in an IANA time zone. (20:30:00)
+// Padding is specified inside the braces, e.g. %{elapsed:010X}.
+// The prefix can be styled with %{fg:color} and %{bg:color}, the color is a name (cyan, bright-red, ...), a number of
+// the 256 color palette (208) or a truecolor (#ff8000). %{bold}, %{dim}, %{italic}, %{underline}, %{blink},
+// %{reverse}, %{hidden} and %{strike} set attributes, %{reset} resets the style.
+// Directives without a namespace are resolved in defaultNamespace, so the formats of FormatTime or FormatDuration
+// keep working as they are.
+func FormatStamp(s Stamp, f, defaultNamespace string) string {
+	c := Compiler{DefaultNamespace: defaultNamespace}
+	return c.mustCompile(f).Format(s)
+}
+
+func isNamespace(namespace string) bool {
+	switch namespace {
+	case TimeNamespace, ElapsedNamespace, DeltaNamespace, LayoutNamespace:
+		return true
+	default:
+		return false
+	}
 }
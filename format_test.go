@@ -7,6 +7,57 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestFormatTime(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	tm := time.Date(2019, 2, 7, 9, 6, 5, 123456789, berlin)
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%a %A %w %u", "Thu Thursday 4 4"},
+		{"%d|%e|%b|%h|%B|%m", "7| 7|Feb|Feb|February|2"},
+		{"%y|%Y|%C|%G|%g|%V", "19|2019|20|2019|19|06"},
+		{"%H|%k|%I|%l|%p|%P", "9| 9|9| 9|AM|am"},
+		{"%M|%S|%f|%L|%N|%s", "6|5|123456|123|123456789|1549526765"},
+		{"%z|%:z|%Z", "+0100|+01:00|CET"},
+		{"%j|%U|%W", "38|5|5"},
+		{"%c|%x|%X", "Thu Feb 7 09:06:05 2019|02/07/19|09:06:05"},
+		{"%D|%F|%T|%R", "02/07/19|2019-02-07|09:06:05|09:06"},
+		{"%n|%t|%%", "\n|\t|%"},
+		{"%3e|%03k|%-4:z|", "  7|0 9|+01:00|"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			require.NoError(t, ValidateTime(test.format))
+			require.Equal(t, test.expected, FormatTime(tm, test.format))
+		})
+	}
+
+	t.Run("Midnight", func(t *testing.T) {
+		midnight := time.Date(2019, 2, 7, 0, 0, 0, 0, time.UTC)
+		require.Equal(t, "0| 0|12|12|AM|am|00:00", FormatTime(midnight, "%H|%k|%I|%l|%p|%P|%R"))
+		noon := time.Date(2019, 2, 7, 12, 0, 0, 0, time.UTC)
+		require.Equal(t, "12|12|12|12|PM|pm|12:00", FormatTime(noon, "%H|%k|%I|%l|%p|%P|%R"))
+		require.Equal(t, "0|+0000|+00:00", FormatTime(time.Unix(0, 0).UTC(), "%s|%z|%:z"))
+	})
+
+	t.Run("ISO Week", func(t *testing.T) {
+		for _, test := range []struct {
+			time     time.Time
+			expected string
+		}{
+			{time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC), "2020-W53-4 20"},
+			{time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), "2020-W53-7 20"},
+			{time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC), "2021-W01-1 21"},
+			{time.Date(2018, 12, 31, 0, 0, 0, 0, time.UTC), "2019-W01-1 19"},
+			{time.Date(2009, 12, 31, 0, 0, 0, 0, time.UTC), "2009-W53-4 09"},
+			{time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), "2009-W53-5 09"},
+		} {
+			require.Equal(t, test.expected, FormatTime(test.time, "%G-W%V-%u %g"))
+		}
+	})
+}
+
 func TestFormatDuration(t *testing.T) {
 	mustParse := func(s string) time.Duration {
 		a, err := time.ParseDuration(s)
@@ -19,4 +70,74 @@ func TestFormatDuration(t *testing.T) {
 	require.Equal(t, "03:58:44.000000789", FormatDuration(mustParse("3h58m44s789ns"), "%Xn"))
 	require.Equal(t, "03:58:44.999999999", FormatDuration(mustParse("3h58m44s999999999ns"), "%Xn"))
 	require.Equal(t, "03:58:45.000000001", FormatDuration(mustParse("3h58m44s1000000001ns"), "%Xn"))
+	require.Equal(t, "-00:00:05.250000", FormatDuration(mustParse("-5.25s"), "%Xf"))
+
+	t.Run("Components", func(t *testing.T) {
+		d := mustParse("85h30m4s123456789ns")
+		require.Equal(t, "3d 13:30:04.123", FormatDuration(d, "%Dd %02H:%02M:%02S.%L"))
+		require.Equal(t, "123|123456|123456789", FormatDuration(d, "%L|%f|%N"))
+		require.Equal(t, "307804.123456789|307804.123|307804", FormatDuration(d, "%s|%.3s|%.0s"))
+		require.Equal(t, "   4.50", FormatDuration(mustParse("4.5s"), "%7.2s"))
+	})
+
+	t.Run("Readable", func(t *testing.T) {
+		for _, test := range []struct {
+			duration string
+			format   string
+			expected string
+		}{
+			{"1h2m3.4s", "%R", "1h02m03.4s"},
+			{"85h30m4.99s", "%R", "3d13h30m04.9s"},
+			{"3.456s", "%R", "3.4s"},
+			{"3.456s", "%.3R", "3.456s"},
+			{"2m3.456s", "%.0R", "2m03s"},
+			{"0s", "%R", "0.0s"},
+			{"24h", "%R", "1d00h00m00.0s"},
+			{"-1m5s", "%R", "-1m05.0s"},
+		} {
+			require.Equal(t, test.expected, FormatDuration(mustParse(test.duration), test.format), test.duration)
+		}
+	})
+
+	t.Run("Compact", func(t *testing.T) {
+		for _, test := range []struct {
+			duration string
+			expected string
+		}{
+			{"0s", "0ns"},
+			{"45ns", "45ns"},
+			{"12.5µs", "12µs"},
+			{"850ms", "850ms"},
+			{"12.34s", "12.3s"},
+			{"4m5s", "4m05s"},
+			{"2h5m59s", "2h05m"},
+			{"85h30m4s", "3d13h"},
+			{"-850ms", "-850ms"},
+		} {
+			require.Equal(t, test.expected, FormatDuration(mustParse(test.duration), "%A"), test.duration)
+		}
+	})
+}
+
+func TestFormatStamp(t *testing.T) {
+	start := time.Date(2019, 2, 7, 11, 26, 45, 0, time.UTC)
+	s := Stamp{
+		Time:     start.Add(3*time.Minute + 12*time.Second + 4*time.Millisecond),
+		Start:    start,
+		Previous: start.Add(3*time.Minute + 11*time.Second),
+	}
+
+	require.Equal(t, "[11:29:57 +00:03:12.004000] ", FormatStamp(s, "[%X +%{elapsed:Xf}] ", TimeNamespace))
+	require.Equal(t, "[00:03:12 11:29:57] ", FormatStamp(s, "[%X %{time:X}] ", ElapsedNamespace))
+	require.Equal(t, "[00:00:01 00:03:12 11:29:57] ", FormatStamp(s, "[%{delta:X} %{elapsed:X} %{time:X}] ", TimeNamespace))
+	require.Equal(t, "[00:00:01.004000] ", FormatStamp(s, "[%Xf] ", DeltaNamespace))
+	require.Equal(t, "[0000:03:12] ", FormatStamp(s, "[%{elapsed:010X}] ", TimeNamespace))
+	require.Equal(t, "[2019 Feb 00:03:12] ", FormatStamp(s, "[%Y %b %{elapsed:X}] ", TimeNamespace))
+
+	t.Run("Literals", func(t *testing.T) {
+		require.Equal(t, "%{elapsed:X} 11:29:57", FormatStamp(s, "%%{elapsed:X} %X", TimeNamespace))
+		require.Equal(t, "%{unknown:X} 11:29:57", FormatStamp(s, "%{unknown:X} %X", TimeNamespace))
+		require.Equal(t, "%{elapsed:X 11:29:57", FormatStamp(s, "%{elapsed:X %X", TimeNamespace))
+		require.Equal(t, "11:29:57%", FormatStamp(s, "%X%", TimeNamespace))
+	})
 }
@@ -4,7 +4,6 @@ go 1.21.3
 
 require (
 	github.com/Eun/mapprint v1.1.1
-	github.com/pborman/ansi v1.0.0
 	github.com/spf13/cobra v1.8.1
 	github.com/stretchr/testify v1.10.0
 )
@@ -0,0 +1,99 @@
+package logtimer
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONField is a field that JSONInjectEncoder inserts into JSON objects.
+type JSONField struct {
+	// Name is the key of the field, e.g. logtimer_ts.
+	Name string
+	// Format formats the value of the field, the value is a JSON string.
+	Format *Formatter
+}
+
+// JSONInjectEncoder inserts Fields at the start of lines that are JSON objects, the keys of the object keep their
+// order and the line is not reformatted:
+//
+//	{"level":"info","msg":"started"}
+//	{"logtimer_ts":"2019-02-07T11:26:45.123+01:00","level":"info","msg":"started"}
+//
+// A field whose name is already a key of the object is skipped, so the line keeps its own value and no key is
+// duplicated. Lines that are not JSON objects are prefixed with Fallback, like PrefixReader does. If Fallback is nil
+// they are written unchanged.
+type JSONInjectEncoder struct {
+	Fields   []JSONField
+	Fallback *Formatter
+}
+
+// AppendRecord appends the line of r with the fields inserted, or the prefixed line, and its delimiter to dst.
+func (e JSONInjectEncoder) AppendRecord(dst []byte, r Record) []byte {
+	start := jsonObjectStart(r.Line)
+	if start < 0 {
+		if e.Fallback != nil {
+			dst = e.Fallback.AppendFormat(dst, r.Stamp)
+		}
+		dst = append(dst, r.Line...)
+		return append(dst, r.Delimiter...)
+	}
+
+	dst = append(dst, r.Line[:start+1]...)
+	var keys map[string]bool
+	if len(e.Fields) > 0 {
+		keys = jsonObjectKeys(r.Line)
+	}
+	var value []byte
+	written := 0
+	for _, field := range e.Fields {
+		if keys[field.Name] {
+			continue
+		}
+		if written > 0 {
+			dst = append(dst, ',')
+		}
+		written++
+		dst = appendJSONString(dst, []byte(field.Name))
+		dst = append(dst, ':')
+		value = field.Format.AppendFormat(value[:0], r.Stamp)
+		dst = appendJSONString(dst, value)
+	}
+	rest := r.Line[start+1:]
+	if written > 0 && bytes.TrimLeft(rest, " \t\r\n")[0] != '}' {
+		dst = append(dst, ',')
+	}
+	dst = append(dst, rest...)
+	return append(dst, r.Delimiter...)
+}
+
+// jsonObjectStart returns the position of the opening brace if line is a JSON object, otherwise -1.
+func jsonObjectStart(line []byte) int {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' || !json.Valid(trimmed) {
+		return -1
+	}
+	return bytes.IndexByte(line, '{')
+}
+
+// jsonObjectKeys returns the keys of the JSON object line.
+func jsonObjectKeys(line []byte) map[string]bool {
+	keys := make(map[string]bool)
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if _, err := dec.Token(); err != nil {
+		return keys
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return keys
+		}
+		if key, ok := token.(string); ok {
+			keys[key] = true
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
@@ -0,0 +1,69 @@
+package logtimer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONInjectEncoder(t *testing.T) {
+	c := Compiler{Location: time.UTC}
+	e := JSONInjectEncoder{
+		Fields: []JSONField{
+			{Name: "logtimer_ts", Format: c.mustCompile("%F %T.%L")},
+			{Name: "logtimer_elapsed", Format: c.mustCompile("%{elapsed:R}")},
+		},
+		Fallback: c.mustCompile("[%T] "),
+	}
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{`{"level":"info","msg":"started"}`,
+			`{"logtimer_ts":"2019-02-07 11:26:46.500","logtimer_elapsed":"1.5s","level":"info","msg":"started"}`},
+		{`{}`, `{"logtimer_ts":"2019-02-07 11:26:46.500","logtimer_elapsed":"1.5s"}`},
+		{`  { "z": 1, "a": [1, 2], "m": {"k": null} }  `,
+			`  {"logtimer_ts":"2019-02-07 11:26:46.500","logtimer_elapsed":"1.5s", "z": 1, "a": [1, 2], "m": {"k": null} }  `},
+		{`{ }`, `{"logtimer_ts":"2019-02-07 11:26:46.500","logtimer_elapsed":"1.5s" }`},
+		// existing keys keep their value, nested keys do not count
+		{`{"logtimer_ts":"own","msg":"started"}`,
+			`{"logtimer_elapsed":"1.5s","logtimer_ts":"own","msg":"started"}`},
+		{`{"logtimer_elapsed":1,"logtimer_ts":2}`, `{"logtimer_elapsed":1,"logtimer_ts":2}`},
+		{`{"m":{"logtimer_ts":"nested"},"logtimer_\u0065lapsed":1}`,
+			`{"logtimer_ts":"2019-02-07 11:26:46.500","m":{"logtimer_ts":"nested"},"logtimer_\u0065lapsed":1}`},
+		{`plain text`, `[11:26:46] plain text`},
+		{`{"broken":`, `[11:26:46] {"broken":`},
+		{`[1, 2]`, `[11:26:46] [1, 2]`},
+		{`{"a":1} trailing`, `[11:26:46] {"a":1} trailing`},
+		{``, `[11:26:46] `},
+	}
+	for _, test := range tests {
+		r := testRecord(test.line)
+		r.Delimiter = []byte("\n")
+		require.Equal(t, test.expected+"\n", string(e.AppendRecord(nil, r)), "%q", test.line)
+	}
+
+	t.Run("Without Fields", func(t *testing.T) {
+		r := testRecord(`{"a":1}`)
+		require.Equal(t, `{"a":1}`, string(JSONInjectEncoder{}.AppendRecord(nil, r)))
+		r = testRecord(`text`)
+		require.Equal(t, `text`, string(JSONInjectEncoder{}.AppendRecord(nil, r)))
+	})
+
+	t.Run("Record Reader", func(t *testing.T) {
+		start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+		reader := &RecordReader{
+			Reader:  strings.NewReader("{\"msg\":\"json\"}\r\ntext\r\n"),
+			Encoder: e,
+			Clock:   NewFakeClock(start, time.Second),
+		}
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, "{\"logtimer_ts\":\"2019-02-07 11:26:46.000\",\"logtimer_elapsed\":\"1.0s\",\"msg\":\"json\"}\r\n"+
+			"[11:26:46] text\r\n", string(out))
+	})
+}
@@ -0,0 +1,112 @@
+package logtimer
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// ANSI defines how the escape sequences of a line, e.g. colors, are encoded.
+type ANSI int
+
+const (
+	// KeepANSI keeps the escape sequences in the line.
+	KeepANSI ANSI = iota
+	// StripANSI removes the escape sequences from the line.
+	StripANSI
+	// SeparateANSI removes the escape sequences from the line and stores the original line in a separate field.
+	SeparateANSI
+)
+
+// line returns the line of r as it is encoded with a.
+func (a ANSI) line(r Record) []byte {
+	if a == KeepANSI {
+		return r.Line
+	}
+	return stripEscapes(nil, r.Line)
+}
+
+// recordTime returns the time of r in loc, if loc is nil the location of the Stamp is used.
+func recordTime(r Record, loc *time.Location) time.Time {
+	if loc == nil {
+		return r.Time
+	}
+	return r.Time.In(loc)
+}
+
+// JSONEncoder encodes a Record as a JSON object on a line of its own (JSON Lines):
+//
+//	{"ts":"2019-02-07T11:26:45.123+01:00","elapsed_ns":123000000,"delta_ns":123000000,"line":"Hello","stream":"stdout","seq":1}
+//
+// stream is omitted if the Record has no Stream, with SeparateANSI the original line is stored in raw.
+type JSONEncoder struct {
+	// Location is the time zone of ts, if it is nil the location of the Stamp is used.
+	Location *time.Location
+	ANSI     ANSI
+}
+
+// AppendRecord appends the JSON object of r and a line feed to dst.
+func (e JSONEncoder) AppendRecord(dst []byte, r Record) []byte {
+	dst = append(dst, `{"ts":"`...)
+	dst = recordTime(r, e.Location).AppendFormat(dst, time.RFC3339Nano)
+	dst = append(dst, `","elapsed_ns":`...)
+	dst = strconv.AppendInt(dst, int64(r.Elapsed()), 10)
+	dst = append(dst, `,"delta_ns":`...)
+	dst = strconv.AppendInt(dst, int64(r.Delta()), 10)
+	dst = append(dst, `,"line":`...)
+	dst = appendJSONString(dst, e.ANSI.line(r))
+	if e.ANSI == SeparateANSI {
+		dst = append(dst, `,"raw":`...)
+		dst = appendJSONString(dst, r.Line)
+	}
+	if r.Stream != "" {
+		dst = append(dst, `,"stream":`...)
+		dst = appendJSONString(dst, []byte(r.Stream))
+	}
+	dst = append(dst, `,"seq":`...)
+	dst = strconv.AppendInt(dst, int64(r.Seq), 10)
+	return append(dst, "}\n"...)
+}
+
+// appendJSONString appends s as a quoted JSON string to dst, invalid UTF-8 is replaced by U+FFFD.
+func appendJSONString(dst, s []byte) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(s[i:])
+			switch {
+			case r == utf8.RuneError && size == 1:
+				dst = append(dst, "\ufffd"...)
+			case r == '\u2028' || r == '\u2029':
+				// valid JSON, but not valid JavaScript
+				dst = append(dst, `\u202`...)
+				dst = append(dst, hex[r&0xf])
+			default:
+				dst = append(dst, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+		switch b {
+		case '"', '\\':
+			dst = append(dst, '\\', b)
+		case '\n':
+			dst = append(dst, `\n`...)
+		case '\r':
+			dst = append(dst, `\r`...)
+		case '\t':
+			dst = append(dst, `\t`...)
+		default:
+			if b < 0x20 || b == 0x7f {
+				dst = append(dst, `\u00`...)
+				dst = append(dst, hex[b>>4], hex[b&0xf])
+			} else {
+				dst = append(dst, b)
+			}
+		}
+		i++
+	}
+	return append(dst, '"')
+}
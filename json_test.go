@@ -0,0 +1,72 @@
+package logtimer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+	r := Record{
+		Stamp: Stamp{
+			Time:     start.Add(1500 * time.Millisecond),
+			Start:    start,
+			Previous: start.Add(time.Second),
+			Seq:      2,
+		},
+		Line:   []byte("\x1b[31mHello\x1b[0m \"World\"\t<&>"),
+		Stream: "stderr",
+	}
+
+	t.Run("Keep ANSI", func(t *testing.T) {
+		require.Equal(t,
+			`{"ts":"2019-02-07T11:26:46.5Z","elapsed_ns":1500000000,"delta_ns":500000000,`+
+				`"line":"\u001b[31mHello\u001b[0m \"World\"\t<&>","stream":"stderr","seq":2}`+"\n",
+			string(JSONEncoder{}.AppendRecord(nil, r)))
+	})
+
+	t.Run("Strip ANSI", func(t *testing.T) {
+		require.Equal(t,
+			`{"ts":"2019-02-07T12:26:46.5+01:00","elapsed_ns":1500000000,"delta_ns":500000000,`+
+				`"line":"Hello \"World\"\t<&>","stream":"stderr","seq":2}`+"\n",
+			string(JSONEncoder{Location: time.FixedZone("CET", 3600), ANSI: StripANSI}.AppendRecord(nil, r)))
+	})
+
+	t.Run("Separate ANSI", func(t *testing.T) {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal(JSONEncoder{ANSI: SeparateANSI}.AppendRecord(nil, r), &v))
+		require.Equal(t, "Hello \"World\"\t<&>", v["line"])
+		require.Equal(t, string(r.Line), v["raw"])
+	})
+
+	t.Run("Without Stream", func(t *testing.T) {
+		r := r
+		r.Stream = ""
+		require.NotContains(t, string(JSONEncoder{}.AppendRecord(nil, r)), "stream")
+	})
+}
+
+func TestAppendJSONString(t *testing.T) {
+	inputs := []string{
+		"",
+		"Hello World",
+		"quote \" backslash \\ slash /",
+		"\x00\x01\x1f\x7f\b\f\n\r\t",
+		"日本語 🚀",
+		"invalid \xff\xfe utf-8",
+		"line\u2028separator\u2029",
+	}
+	for _, input := range inputs {
+		encoded := appendJSONString(nil, []byte(input))
+		var decoded string
+		require.NoError(t, json.Unmarshal(encoded, &decoded), "%s", encoded)
+		expected, err := json.Marshal(input)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(expected, &input))
+		require.Equal(t, input, decoded)
+	}
+	require.Equal(t, `"\u2028"`, string(appendJSONString(nil, []byte("\u2028"))))
+}
@@ -0,0 +1,178 @@
+package logtimer
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Locale holds the names and representations that %a, %A, %b, %B, %p, %c, %x and %X use.
+type Locale struct {
+	ShortDayNames   [7]string // Sunday first
+	LongDayNames    [7]string // Sunday first
+	ShortMonthNames [12]string
+	LongMonthNames  [12]string
+	AM              string
+	PM              string
+	// DateTime, Date and Time are the formats of %c, %x and %X, they can use the directives of FormatTime.
+	// %c, %x and %X use the English representation inside of them, as they would if the format is empty.
+	DateTime string
+	Date     string
+	Time     string
+}
+
+// English is the locale that is used if no locale is set.
+var English = Locale{
+	ShortDayNames:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	LongDayNames:    [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	ShortMonthNames: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	LongMonthNames: [12]string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	},
+	AM:       "AM",
+	PM:       "PM",
+	DateTime: "%a %b %d %T %Y",
+	Date:     "%D",
+	Time:     "%T",
+}
+
+var locales = map[string]*Locale{
+	"en": &English,
+	"de": {
+		ShortDayNames:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		LongDayNames:    [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortMonthNames: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		LongMonthNames: [12]string{
+			"Januar", "Februar", "März", "April", "Mai", "Juni",
+			"Juli", "August", "September", "Oktober", "November", "Dezember",
+		},
+		AM:       "AM",
+		PM:       "PM",
+		DateTime: "%a %02d %b %Y %T",
+		Date:     "%02d.%02m.%Y",
+		Time:     "%T",
+	},
+	"fr": {
+		ShortDayNames: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		LongDayNames:  [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortMonthNames: [12]string{
+			"janv.", "févr.", "mars", "avr.", "mai", "juin",
+			"juil.", "août", "sept.", "oct.", "nov.", "déc.",
+		},
+		LongMonthNames: [12]string{
+			"janvier", "février", "mars", "avril", "mai", "juin",
+			"juillet", "août", "septembre", "octobre", "novembre", "décembre",
+		},
+		AM:       "AM",
+		PM:       "PM",
+		DateTime: "%a %02d %b %Y %T",
+		Date:     "%02d/%02m/%Y",
+		Time:     "%T",
+	},
+	"es": {
+		ShortDayNames:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		LongDayNames:    [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortMonthNames: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		LongMonthNames: [12]string{
+			"enero", "febrero", "marzo", "abril", "mayo", "junio",
+			"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+		},
+		AM:       "a. m.",
+		PM:       "p. m.",
+		DateTime: "%a %02d %b %Y %T",
+		Date:     "%02d/%02m/%y",
+		Time:     "%T",
+	},
+	"ja": {
+		ShortDayNames: [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		LongDayNames:  [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		ShortMonthNames: [12]string{
+			"1月", "2月", "3月", "4月", "5月", "6月",
+			"7月", "8月", "9月", "10月", "11月", "12月",
+		},
+		LongMonthNames: [12]string{
+			"1月", "2月", "3月", "4月", "5月", "6月",
+			"7月", "8月", "9月", "10月", "11月", "12月",
+		},
+		AM:       "午前",
+		PM:       "午後",
+		DateTime: "%Y年%02m月%02d日 %02H時%02M分%02S秒",
+		Date:     "%Y年%02m月%02d日",
+		Time:     "%02H時%02M分%02S秒",
+	},
+	"pt": {
+		ShortDayNames: [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+		LongDayNames: [7]string{
+			"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado",
+		},
+		ShortMonthNames: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		LongMonthNames: [12]string{
+			"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+			"julho", "agosto", "setembro", "outubro", "novembro", "dezembro",
+		},
+		AM:       "AM",
+		PM:       "PM",
+		DateTime: "%a %02d %b %Y %T",
+		Date:     "%02d/%02m/%Y",
+		Time:     "%T",
+	},
+}
+
+// LookupLocale returns a bundled locale by its name. Names are matched by their language, so de, de_DE,
+// de-AT and de_DE.UTF-8 all return the German locale. C and POSIX return English.
+// See LocaleNames for all bundled locales.
+func LookupLocale(name string) (*Locale, bool) {
+	// strip the codeset and modifier: de_DE.UTF-8@euro
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	language, _, _ := strings.Cut(strings.ReplaceAll(name, "-", "_"), "_")
+	language = strings.ToLower(language)
+	if language == "c" || language == "posix" {
+		return &English, true
+	}
+	l, ok := locales[language]
+	return l, ok
+}
+
+// LocaleNames returns the names of all bundled locales in alphabetical order.
+func LocaleNames() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnvironmentLocale returns the locale that is set by the LC_ALL, LC_TIME or LANG environment variables, in
+// that order. English is returned if none of them is set to a bundled locale.
+func EnvironmentLocale() *Locale {
+	for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		name := os.Getenv(key)
+		if name == "" {
+			continue
+		}
+		if l, ok := LookupLocale(name); ok {
+			return l
+		}
+		// like setlocale the first variable that is set decides
+		return &English
+	}
+	return &English
+}
+
+// format returns the format of %c, %x or %X.
+func (l *Locale) format(directive string) string {
+	switch directive {
+	case "c":
+		return l.DateTime
+	case "x":
+		return l.Date
+	case "X":
+		return l.Time
+	default:
+		return ""
+	}
+}
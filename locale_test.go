@@ -0,0 +1,104 @@
+package logtimer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocale(t *testing.T) {
+	tm := time.Date(2019, 2, 7, 15, 6, 5, 0, time.UTC)
+	tests := []struct {
+		locale   string
+		expected string
+	}{
+		{"en", "Thu Thursday Feb February PM pm|Thu Feb 7 15:06:05 2019|02/07/19|15:06:05"},
+		{"de", "Do Donnerstag Feb Februar PM pm|Do 07 Feb 2019 15:06:05|07.02.2019|15:06:05"},
+		{"fr", "jeu. jeudi févr. février PM pm|jeu. 07 févr. 2019 15:06:05|07/02/2019|15:06:05"},
+		{"es", "jue jueves feb febrero p. m. p. m.|jue 07 feb 2019 15:06:05|07/02/19|15:06:05"},
+		{"ja", "木 木曜日 2月 2月 午後 午後|2019年02月07日 15時06分05秒|2019年02月07日|15時06分05秒"},
+		{"pt", "qui quinta-feira fev fevereiro PM pm|qui 07 fev 2019 15:06:05|07/02/2019|15:06:05"},
+	}
+	for _, test := range tests {
+		t.Run(test.locale, func(t *testing.T) {
+			l, ok := LookupLocale(test.locale)
+			require.True(t, ok)
+			c := Compiler{Locale: l}
+			f, err := c.Compile("%a %A %b %B %p %P|%c|%x|%X")
+			require.NoError(t, err)
+			require.Equal(t, test.expected, f.Format(Stamp{Time: tm}))
+		})
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		f, err := Compile("%a %b %p|%c|%x|%X")
+		require.NoError(t, err)
+		require.Equal(t, "Thu Feb PM|Thu Feb 7 15:06:05 2019|02/07/19|15:06:05", f.Format(Stamp{Time: tm}))
+	})
+
+	t.Run("Location", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		l, _ := LookupLocale("de")
+		c := Compiler{Locale: l, Location: tokyo}
+		f, err := c.Compile("%c|%{time@UTC:c}")
+		require.NoError(t, err)
+		require.Equal(t, "Fr 08 Feb 2019 00:06:05|Do 07 Feb 2019 15:06:05", f.Format(Stamp{Time: tm}))
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		l := English
+		l.DateTime = "%x %c"
+		l.Date = "%F"
+		c := Compiler{Locale: &l}
+		f, err := c.Compile("%c")
+		require.NoError(t, err)
+		require.Equal(t, "02/07/19 Thu Feb 7 15:06:05 2019", f.Format(Stamp{Time: tm}))
+	})
+
+	t.Run("No Allocations", func(t *testing.T) {
+		l, _ := LookupLocale("ja")
+		c := Compiler{Locale: l}
+		f, err := c.Compile("[%c %a %p] ")
+		require.NoError(t, err)
+		buf := make([]byte, 0, 256)
+		allocs := testing.AllocsPerRun(100, func() {
+			buf = f.AppendFormat(buf[:0], Stamp{Time: tm})
+		})
+		require.Zero(t, allocs)
+	})
+}
+
+func TestLookupLocale(t *testing.T) {
+	for _, name := range []string{"de", "DE", "de_DE", "de-AT", "de_DE.UTF-8", "de_DE@euro"} {
+		l, ok := LookupLocale(name)
+		require.True(t, ok, name)
+		require.Equal(t, "Montag", l.LongDayNames[1], name)
+	}
+	for _, name := range []string{"C", "POSIX", "C.UTF-8", "en_US.UTF-8"} {
+		l, ok := LookupLocale(name)
+		require.True(t, ok, name)
+		require.Equal(t, &English, l, name)
+	}
+	_, ok := LookupLocale("xx_XX")
+	require.False(t, ok)
+
+	require.Equal(t, []string{"de", "en", "es", "fr", "ja", "pt"}, LocaleNames())
+}
+
+func TestEnvironmentLocale(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_TIME", "")
+	t.Setenv("LANG", "")
+	require.Equal(t, &English, EnvironmentLocale())
+
+	t.Setenv("LANG", "fr_FR.UTF-8")
+	require.Equal(t, "lundi", EnvironmentLocale().LongDayNames[1])
+
+	t.Setenv("LC_TIME", "es_ES.UTF-8")
+	require.Equal(t, "lunes", EnvironmentLocale().LongDayNames[1])
+
+	t.Setenv("LC_ALL", "xx_XX.UTF-8")
+	require.Equal(t, &English, EnvironmentLocale())
+}
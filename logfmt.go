@@ -0,0 +1,56 @@
+package logtimer
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtEncoder encodes a Record as a logfmt line:
+//
+//	ts=2019-02-07T11:26:45.123+01:00 elapsed=123ms delta=123ms stream=stdout seq=1 msg="Hello World"
+//
+// stream is omitted if the Record has no Stream, with SeparateANSI the original line is stored in raw.
+type LogfmtEncoder struct {
+	// Location is the time zone of ts, if it is nil the location of the Stamp is used.
+	Location *time.Location
+	ANSI     ANSI
+}
+
+// AppendRecord appends the logfmt line of r and a line feed to dst.
+func (e LogfmtEncoder) AppendRecord(dst []byte, r Record) []byte {
+	dst = append(dst, "ts="...)
+	dst = recordTime(r, e.Location).AppendFormat(dst, time.RFC3339Nano)
+	dst = append(dst, " elapsed="...)
+	dst = append(dst, r.Elapsed().String()...)
+	dst = append(dst, " delta="...)
+	dst = append(dst, r.Delta().String()...)
+	if r.Stream != "" {
+		dst = append(dst, " stream="...)
+		dst = appendLogfmtValue(dst, []byte(r.Stream))
+	}
+	dst = append(dst, " seq="...)
+	dst = strconv.AppendInt(dst, int64(r.Seq), 10)
+	dst = append(dst, " msg="...)
+	dst = appendLogfmtValue(dst, e.ANSI.line(r))
+	if e.ANSI == SeparateANSI {
+		dst = append(dst, " raw="...)
+		dst = appendLogfmtValue(dst, r.Line)
+	}
+	return append(dst, '\n')
+}
+
+// appendLogfmtValue appends the value s to dst, it is quoted if it is empty or contains spaces, quotes, equal signs,
+// control characters or invalid UTF-8.
+func appendLogfmtValue(dst, s []byte) []byte {
+	if len(s) == 0 || bytes.ContainsAny(s, ` "=\`) || !utf8.Valid(s) || bytes.ContainsFunc(s, isControl) {
+		return appendJSONString(dst, s)
+	}
+	return append(dst, s...)
+}
+
+// isControl reports whether r is a control character.
+func isControl(r rune) bool {
+	return r < 0x20 || (r >= 0x7f && r < 0xa0)
+}
@@ -0,0 +1,29 @@
+package logtimer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	tests := []struct {
+		encoder  LogfmtEncoder
+		line     string
+		expected string
+	}{
+		{LogfmtEncoder{}, "Hello", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg=Hello`},
+		{LogfmtEncoder{}, "", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg=""`},
+		{LogfmtEncoder{}, `say "hi"`, `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg="say \"hi\""`},
+		{LogfmtEncoder{}, "a=b\\c", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg="a=b\\c"`},
+		{LogfmtEncoder{}, "tab\tnew\nline", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg="tab\tnew\nline"`},
+		{LogfmtEncoder{}, "\x1b[31mred\x1b[0m", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg="\u001b[31mred\u001b[0m"`},
+		{LogfmtEncoder{ANSI: StripANSI}, "\x1b[31mred\x1b[0m", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg=red`},
+		{LogfmtEncoder{ANSI: SeparateANSI}, "\x1b[31mred\x1b[0m", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg=red raw="\u001b[31mred\u001b[0m"`},
+		{LogfmtEncoder{}, "日本語", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg=日本語`},
+		{LogfmtEncoder{}, "\xff", `ts=2019-02-07T11:26:46.5Z elapsed=1.5s delta=500ms stream=stdout seq=2 msg="�"`},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected+"\n", string(test.encoder.AppendRecord(nil, testRecord(test.line))), "%q", test.line)
+	}
+}
@@ -0,0 +1,234 @@
+package logtimer
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CarriageReturn defines how a carriage return that is not followed by a line feed is handled, programs like
+// curl, docker pull or pip use them to redraw a progress bar.
+type CarriageReturn int
+
+const (
+	// IgnoreCarriageReturn passes carriage returns through, redraws overwrite the prefix.
+	IgnoreCarriageReturn CarriageReturn = iota
+	// RestartCarriageReturn treats a carriage return as the start of a line and writes a new prefix after it.
+	RestartCarriageReturn
+	// CollapseCarriageReturn writes only the final state of a redrawn line, which suits outputs that are not
+	// terminals. Lines are held back until they are complete.
+	CollapseCarriageReturn
+)
+
+// defaultDelimiter ends a line if no delimiter is set.
+var defaultDelimiter = []byte{'\n'}
+
+// prefixOptions are the settings of PrefixReader and PrefixWriter that are used by the prefixer.
+type prefixOptions struct {
+	format          FormatFunc
+	formatAt        FormatAtFunc
+	colorCorrection ColorCorrection
+	carriageReturn  CarriageReturn
+	delimiter       []byte
+	arrival         time.Time
+	// earlyPrefix formats the prefix of a line when the previous line ends
+	earlyPrefix bool
+}
+
+// prefix returns the prefix of a line that starts with the bytes that are written.
+func (o prefixOptions) prefix() string {
+	if o.formatAt != nil {
+		return o.formatAt(o.arrival)
+	}
+	return o.format()
+}
+
+// delimiterOrDefault returns delimiter, or a line feed if it is empty.
+func delimiterOrDefault(delimiter []byte) []byte {
+	if len(delimiter) == 0 {
+		return defaultDelimiter
+	}
+	return delimiter
+}
+
+// prefixer holds the line state that is shared between PrefixReader and PrefixWriter.
+type prefixer struct {
+	skipNextPrint bool
+	sgr           sgrTracker
+	matcher       delimiterMatcher
+	// a carriage return of RestartCarriageReturn restarts the line unless a line feed follows
+	pendingCR bool
+	// prefix and line hold the current line for CollapseCarriageReturn
+	prefix bytes.Buffer
+	line   []byte
+	// next is the prefix of the next line for earlyPrefix
+	next    string
+	hasNext bool
+}
+
+// write writes p to buf and inserts a prefix at the start of every line.
+// It returns the length of buf after the last completed line, or -1.
+func (pr *prefixer) write(buf *bytes.Buffer, p []byte, opts prefixOptions) int {
+	complete := -1
+	for _, b := range p {
+		if pr.pendingCR && b != '\n' {
+			pr.writePrefix(buf, opts.prefix(), opts.colorCorrection)
+		}
+		pr.pendingCR = false
+
+		if !pr.skipNextPrint {
+			pr.startLine(buf, opts)
+		}
+		if opts.colorCorrection == Tracking {
+			pr.sgr.feed(b)
+		}
+
+		if opts.carriageReturn == CollapseCarriageReturn {
+			pr.line = append(pr.line, b)
+		} else {
+			_ = buf.WriteByte(b)
+			pr.pendingCR = b == '\r' && opts.carriageReturn == RestartCarriageReturn
+			if pr.pendingCR {
+				complete = buf.Len()
+			}
+		}
+
+		if pr.matcher.match(b, opts.delimiter) {
+			pr.endLine(buf, opts)
+			complete = buf.Len()
+		}
+	}
+	return complete
+}
+
+// delimiterMatcher finds a delimiter in a stream of bytes, a delimiter can be split across writes.
+type delimiterMatcher struct {
+	// matched is the number of bytes of the delimiter that have been seen
+	matched int
+}
+
+// match advances the delimiter state by b and reports whether the delimiter is complete.
+func (m *delimiterMatcher) match(b byte, delimiter []byte) bool {
+	if delimiter[m.matched] == b {
+		m.matched++
+		if m.matched < len(delimiter) {
+			return false
+		}
+		m.matched = 0
+		return true
+	}
+	// fall back to the longest start of the delimiter that the seen bytes end with, e.g. "aa" + "b" for "aab"
+	seen := m.matched
+	m.matched = 0
+	for k := seen; k > 0; k-- {
+		if delimiter[k-1] == b && bytes.Equal(delimiter[:k-1], delimiter[seen-k+1:seen]) {
+			m.matched = k
+			break
+		}
+	}
+	return false
+}
+
+// flush writes a pending line of CollapseCarriageReturn to buf.
+func (pr *prefixer) flush(buf *bytes.Buffer, opts prefixOptions) {
+	if opts.carriageReturn != CollapseCarriageReturn || !pr.skipNextPrint {
+		return
+	}
+	pr.writeLine(buf, 0)
+}
+
+func (pr *prefixer) startLine(buf *bytes.Buffer, opts prefixOptions) {
+	pr.skipNextPrint = true
+	if opts.carriageReturn == CollapseCarriageReturn {
+		// the prefix is formatted when the line starts, but written with the final state of the line
+		pr.writePrefix(&pr.prefix, pr.linePrefix(opts), opts.colorCorrection)
+		return
+	}
+	pr.writePrefix(buf, pr.linePrefix(opts), opts.colorCorrection)
+}
+
+// linePrefix returns the prefix of the line that starts.
+func (pr *prefixer) linePrefix(opts prefixOptions) string {
+	if pr.hasNext {
+		pr.hasNext = false
+		return pr.next
+	}
+	return opts.prefix()
+}
+
+func (pr *prefixer) endLine(buf *bytes.Buffer, opts prefixOptions) {
+	if opts.carriageReturn == CollapseCarriageReturn {
+		pr.writeLine(buf, len(opts.delimiter))
+	}
+	pr.skipNextPrint = false
+	pr.pendingCR = false
+	if opts.earlyPrefix {
+		pr.next, pr.hasNext = opts.prefix(), true
+	}
+}
+
+// writeLine writes the prefix and the final state of the current line of CollapseCarriageReturn, the line ends
+// with a delimiter of delimiterLength bytes.
+func (pr *prefixer) writeLine(buf *bytes.Buffer, delimiterLength int) {
+	text := pr.line[:len(pr.line)-delimiterLength]
+	// \r\n is a line ending, not a redraw
+	text, cr := bytes.CutSuffix(text, []byte{'\r'})
+	_, _ = buf.Write(pr.prefix.Bytes())
+	_, _ = buf.Write(collapse(text))
+	if cr && delimiterLength > 0 {
+		_ = buf.WriteByte('\r')
+	}
+	_, _ = buf.Write(pr.line[len(pr.line)-delimiterLength:])
+	pr.prefix.Reset()
+	pr.line = pr.line[:0]
+}
+
+// collapse returns the final state of a line that is redrawn with carriage returns.
+func collapse(line []byte) []byte {
+	parts := bytes.Split(line, []byte{'\r'})
+	state := parts[0]
+	for _, part := range parts[1:] {
+		state = overlay(state, part)
+	}
+	return state
+}
+
+// overlay returns the line a terminal shows if top is written over base after a carriage return.
+func overlay(base, top []byte) []byte {
+	if len(top) == 0 {
+		return base
+	}
+	if bytes.IndexByte(base, 0x1b) >= 0 || bytes.IndexByte(top, 0x1b) >= 0 {
+		// without interpreting the escape sequences the columns are unknown, assume top is a full redraw
+		return top
+	}
+	i := 0
+	for n := utf8.RuneCount(top); n > 0 && i < len(base); n-- {
+		_, size := utf8.DecodeRune(base[i:])
+		i += size
+	}
+	line := make([]byte, 0, len(top)+len(base)-i)
+	line = append(line, top...)
+	return append(line, base[i:]...)
+}
+
+// writePrefix writes the prefix f to buf.
+func (pr *prefixer) writePrefix(buf *bytes.Buffer, f string, cc ColorCorrection) {
+	if cc != Tracking {
+		_, _ = writeFormat(buf, f, cc)
+		return
+	}
+	active := pr.sgr.active()
+	if active {
+		_, _ = buf.WriteString("\x1b[0m")
+	}
+	_, _ = buf.WriteString(f)
+	if strings.IndexByte(f, 0x1b) >= 0 {
+		// the prefix might have changed the attributes
+		_, _ = buf.WriteString("\x1b[0m")
+	}
+	if active {
+		_, _ = buf.Write(pr.sgr.appendSequence(buf.AvailableBuffer()))
+	}
+}
@@ -1,11 +1,9 @@
 package logtimer
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-
-	"github.com/pborman/ansi"
+	"time"
 )
 
 type ColorCorrection int
@@ -14,32 +12,50 @@ const (
 	Disabled ColorCorrection = iota
 	Enabled
 	Alternate
+	// Tracking follows the colors of the stream, resets them before the prefix and re-applies them after it.
+	// It does not move the cursor, so it also works for files, pagers and CI logs.
+	Tracking
 )
 
 type FormatFunc func() string
 
+// FormatAtFunc returns the prefix of a line whose first byte arrived at the arrival time.
+type FormatAtFunc func(arrival time.Time) string
+
+// PrefixReader inserts the output of Format at the start of every line that is read from Reader.
+// The prefix of a line is formatted when the previous line ends, so a line that arrives after an idle gap carries
+// the time the previous line ended, unless DeferPrefix is set.
+// Lines that start in the same Read of Reader share the arrival time of that Read.
 type PrefixReader struct {
-	Format          FormatFunc
-	skipNextPrint   bool
-	buffer          bytes.Buffer
+	Format FormatFunc
+	// FormatAt is used instead of Format if it is set, it receives the arrival time of the line.
+	FormatAt FormatAtFunc
+	// DeferPrefix formats the prefix of a line once its first byte has been read.
+	DeferPrefix     bool
+	prefixer        prefixer
+	buffer          readBuffer
 	ColorCorrection ColorCorrection
+	CarriageReturn  CarriageReturn
+	// Clock is used for the arrival time that is passed to FormatAt, if it is nil SystemClock is used.
+	Clock Clock
+	// Delimiter ends a line, e.g. []byte("\r\n") or []byte{0}, if it is empty a line feed is used.
+	Delimiter []byte
 	io.Reader
 }
 
-func (lt *PrefixReader) writeFormat(w io.Writer) (int, error) { //nolint: unparam // allow unused int return
-	if lt.ColorCorrection == Disabled {
-		return io.WriteString(w, lt.Format())
+func writeFormat(w io.Writer, f string, cc ColorCorrection) (int, error) { //nolint: unparam // allow unused int return
+	if cc == Disabled {
+		return io.WriteString(w, f)
 	}
 
 	saveCursor := "\x1b[s"
 	restoreCursor := "\x1b[u"
 
-	if lt.ColorCorrection == Alternate {
+	if cc == Alternate {
 		saveCursor = "\x1b7"
 		restoreCursor = "\x1b8"
 	}
 
-	f := lt.Format()
 	written, err := fmt.Fprintf(w, "%s\x1b[0m", saveCursor)
 	if err != nil {
 		return 0, err
@@ -50,40 +66,29 @@ func (lt *PrefixReader) writeFormat(w io.Writer) (int, error) { //nolint: unpara
 		return written, err
 	}
 
-	// determinate size
-	r, err := ansi.Strip([]byte(f))
-	if err != nil {
-		return written, err
-	}
-	n, err = fmt.Fprintf(w, "%s\x1b[%dC", restoreCursor, len(r))
+	n, err = fmt.Fprintf(w, "%s\x1b[%dC", restoreCursor, displayWidth(f))
 	written += n
 	return written, err
 }
 
 func (lt *PrefixReader) Read(p []byte) (int, error) {
-	if lt.buffer.Len() > 0 {
-		return lt.buffer.Read(p)
-	}
-	n, err := lt.Reader.Read(p)
-	if err != nil {
-		return n, err
-	}
+	return lt.buffer.read(p, lt.Reader, lt)
+}
 
-	if !lt.skipNextPrint {
-		_, _ = lt.writeFormat(&lt.buffer)
-		lt.skipNextPrint = true
+func (lt *PrefixReader) process(data []byte, err error) {
+	opts := prefixOptions{
+		format:          lt.Format,
+		formatAt:        lt.FormatAt,
+		colorCorrection: lt.ColorCorrection,
+		carriageReturn:  lt.CarriageReturn,
+		delimiter:       delimiterOrDefault(lt.Delimiter),
+		earlyPrefix:     !lt.DeferPrefix,
 	}
-
-	for i := 0; i < n; i++ {
-		_ = lt.buffer.WriteByte(p[i])
-		if p[i] == '\n' {
-			if i < n-1 {
-				_, _ = lt.writeFormat(&lt.buffer)
-			} else {
-				lt.skipNextPrint = false
-			}
-		}
+	if len(data) > 0 && lt.FormatAt != nil {
+		opts.arrival = clockOrDefault(lt.Clock).Now()
+	}
+	_ = lt.prefixer.write(&lt.buffer.Buffer, data, opts)
+	if err == io.EOF {
+		lt.prefixer.flush(&lt.buffer.Buffer, opts)
 	}
-
-	return lt.buffer.Read(p)
 }
@@ -2,9 +2,12 @@ package logtimer
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/require"
@@ -12,10 +15,10 @@ import (
 
 func TestPrefixReader(t *testing.T) {
 	t.Run("Normal Usage", func(t *testing.T) {
-		var in TestBuffer
+		in := make(chan string)
 		var index int
 		reader := &PrefixReader{
-			Reader: &in,
+			Reader: &chanReader{c: in},
 			Format: func() string {
 				defer func() {
 					index++
@@ -23,42 +26,23 @@ func TestPrefixReader(t *testing.T) {
 				return fmt.Sprintf("%d ", index)
 			},
 		}
-
-		copyChan := make(chan error)
-
-		var out bytes.Buffer
 		go func() {
-			_, err := io.Copy(&out, reader)
-			copyChan <- err
+			for _, s := range []string{"Hello World\n", "Hello ", "Universe\n", "Hello\nRest", "\nTe\nst", "\n\n\n", "Foo", "Bar"} {
+				in <- s
+			}
+			close(in)
 		}()
 
-		in.WriteString("Hello World\n")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("Hello ")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("Universe\n")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("Hello\nRest")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("\nTe\nst")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("\n\n\n")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("Foo")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("Bar")
-		in.Close()
-
-		<-copyChan
-
-		require.Equal(t, "0 Hello World\n1 Hello Universe\n2 Hello\n3 Rest\n4 Te\n5 st\n6 \n7 \n8 FooBar", out.String())
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, "0 Hello World\n1 Hello Universe\n2 Hello\n3 Rest\n4 Te\n5 st\n6 \n7 \n8 FooBar", string(out))
 	})
 
 	t.Run("Small buffer", func(t *testing.T) {
-		var in TestBuffer
+		in := make(chan string)
 		var index int
 		reader := &PrefixReader{
-			Reader: &in,
+			Reader: &chanReader{c: in},
 			Format: func() string {
 				defer func() {
 					index++
@@ -66,49 +50,204 @@ func TestPrefixReader(t *testing.T) {
 				return fmt.Sprintf("%d ", index)
 			},
 		}
-
-		copyChan := make(chan error)
+		go func() {
+			// the second line arrives while the prefixed first line is still being read
+			in <- "Hello World\n"
+			in <- "Hello Universe\n"
+			close(in)
+		}()
 
 		var out bytes.Buffer
-		go func() {
-			var p [6]byte
-			for {
-				n, err := reader.Read(p[:])
-				if err != nil {
-					copyChan <- err
-					return
-				}
-				out.Write(p[:n])
+		var p [6]byte
+		for {
+			n, err := reader.Read(p[:])
+			out.Write(p[:n])
+			if err == io.EOF {
+				break
 			}
-		}()
+			require.NoError(t, err)
+		}
+		require.Equal(t, "0 Hello World\n1 Hello Universe\n", out.String())
+	})
 
-		in.WriteString("Hello World\n")
-		time.Sleep(time.Millisecond * 100)
-		in.WriteString("Hello Universe\n")
-		in.Close()
+	t.Run("Arrival Time", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			deferPrefix bool
+			expected    []time.Duration
+		}{
+			// the prefix of the next line is formatted when a line ends, the last one is not written
+			{"Early", false, []time.Duration{0, 0, time.Second, 2 * time.Second}},
+			// the prefix of a line is not formatted before its first byte arrived,
+			// lines that arrive with the same Read share the arrival time
+			{"Deferred", true, []time.Duration{0, time.Second, time.Second}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+				in := make(chan string)
+				var arrivals []time.Duration
+				reader := &PrefixReader{
+					Reader: &chanReader{c: in},
+					FormatAt: func(arrival time.Time) string {
+						arrivals = append(arrivals, arrival.Sub(start))
+						return fmt.Sprintf("%d ", len(arrivals)-1)
+					},
+					DeferPrefix: test.deferPrefix,
+					Clock:       NewFakeClock(start, time.Second),
+				}
+				go func() {
+					in <- "Hello\n"
+					in <- "World\nand"
+					in <- " more\n"
+					close(in)
+				}()
 
-		<-copyChan
+				out, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				require.Equal(t, "0 Hello\n1 World\n2 and more\n", string(out))
+				require.Equal(t, test.expected, arrivals)
+			})
+		}
+	})
 
-		require.Equal(t, "0 Hello World\n1 Hello Universe\n", out.String())
+	t.Run("Data with EOF", func(t *testing.T) {
+		reader := &PrefixReader{
+			Reader: iotest.DataErrReader(strings.NewReader("Hello\nWorld")),
+			Format: func() string {
+				return "> "
+			},
+		}
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, "> Hello\n> World", string(out))
+	})
+
+	t.Run("Data with Error", func(t *testing.T) {
+		errRead := errors.New("read failed")
+		reader := &PrefixReader{
+			Reader: &errReader{data: []byte("Hello\nWor"), err: errRead},
+			Format: func() string {
+				return "> "
+			},
+		}
+		out, err := io.ReadAll(reader)
+		require.ErrorIs(t, err, errRead)
+		require.Equal(t, "> Hello\n> Wor", string(out))
+
+		// the error is kept
+		n, err := reader.Read(make([]byte, 8))
+		require.ErrorIs(t, err, errRead)
+		require.Zero(t, n)
+	})
+
+	t.Run("Delimiters", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			delimiter []byte
+			input     string
+			expected  string
+		}{
+			{"Default", nil, "a\nb\n", "0 a\n1 b\n"},
+			{"NUL", []byte{0}, "a\x00b\nc\x00", "0 a\x001 b\nc\x00"},
+			{"CRLF", []byte("\r\n"), "a\r\nb\nc\rd\r\n", "0 a\r\n1 b\nc\rd\r\n"},
+			{"Sequence", []byte("--\n"), "a\n--\nb-\n--\n", "0 a\n--\n1 b-\n--\n"},
+			{"Overlapping", []byte("aab"), "xaaab yaab", "0 xaaab1  yaab"},
+		}
+		for _, test := range tests {
+			// every chunk size splits the delimiters at a different position
+			for _, size := range []int{1, 2, 3, 64} {
+				t.Run(fmt.Sprintf("%s %d", test.name, size), func(t *testing.T) {
+					var index int
+					reader := &PrefixReader{
+						Reader: &chunkReader{data: []byte(test.input), size: size},
+						Format: func() string {
+							defer func() {
+								index++
+							}()
+							return fmt.Sprintf("%d ", index)
+						},
+						Delimiter: test.delimiter,
+					}
+					out, err := io.ReadAll(reader)
+					require.NoError(t, err)
+					require.Equal(t, test.expected, string(out))
+				})
+			}
+		}
+	})
+
+	t.Run("Collapse Carriage Returns", func(t *testing.T) {
+		var index int
+		reader := &PrefixReader{
+			Reader: iotest.OneByteReader(strings.NewReader("Hello\n10%\r50%\r100%\nDone 1%\rDone 100%")),
+			Format: func() string {
+				defer func() {
+					index++
+				}()
+				return fmt.Sprintf("%d ", index)
+			},
+			CarriageReturn: CollapseCarriageReturn,
+		}
+		// a line that is held back does not end a Read without data
+		p := make([]byte, 64)
+		n, err := reader.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, "0 Hello\n", string(p[:n]))
+		n, err = reader.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, "1 100%\n", string(p[:n]))
+
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, "2 Done 100%", string(out))
 	})
 }
 
-type TestBuffer struct {
-	bytes.Buffer
-	closed bool
+// chanReader returns the strings that are sent to a channel, every string is returned by one Read unless p is too
+// small for it.
+type chanReader struct {
+	c chan string
+	// rest is the part of the last string that did not fit into p
+	rest string
 }
 
-func (b *TestBuffer) Read(p []byte) (int, error) {
-	if b.Buffer.Len() == 0 {
-		if b.closed {
+func (r *chanReader) Read(p []byte) (int, error) {
+	if r.rest == "" {
+		s, ok := <-r.c
+		if !ok {
 			return 0, io.EOF
 		}
-		for b.Buffer.Len() == 0 {
-			time.Sleep(time.Millisecond * 100)
-		}
+		r.rest = s
 	}
-	return b.Buffer.Read(p)
+	n := copy(p, r.rest)
+	r.rest = r.rest[n:]
+	return n, nil
+}
+
+// errReader returns data together with err in a single Read, then err.
+type errReader struct {
+	data []byte
+	err  error
 }
-func (b *TestBuffer) Close() {
-	b.closed = true
+
+func (r *errReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, r.err
+}
+
+// chunkReader returns data in chunks of size bytes.
+type chunkReader struct {
+	data []byte
+	size int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p[:min(len(p), r.size)], r.data)
+	r.data = r.data[n:]
+	return n, nil
 }
@@ -0,0 +1,89 @@
+package logtimer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixWriter is the io.Writer counterpart of PrefixReader, it inserts the output of Format at the start of every
+// line that is written to Writer.
+//
+// Complete lines are passed to Writer in a single Write call, a trailing partial line is held back until it is
+// completed or Flush or Close is called. With RestartCarriageReturn a line is also passed on at every carriage
+// return, so redraws are visible immediately.
+// It is safe to call Write from multiple goroutines, every call is applied as a whole so lines written by one call
+// are never split.
+type PrefixWriter struct {
+	Format FormatFunc
+	// FormatAt is used instead of Format if it is set, it receives the time the first byte of the line was written.
+	FormatAt        FormatAtFunc
+	ColorCorrection ColorCorrection
+	CarriageReturn  CarriageReturn
+	// Clock is used for the arrival time that is passed to FormatAt, if it is nil SystemClock is used.
+	Clock Clock
+	// Delimiter ends a line, e.g. []byte("\r\n") or []byte{0}, if it is empty a line feed is used.
+	Delimiter []byte
+	io.Writer
+
+	mu       sync.Mutex
+	prefixer prefixer
+	buffer   bytes.Buffer
+	// complete is the length of the complete lines at the start of buffer
+	complete int
+}
+
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	opts := w.options()
+	if len(p) > 0 && w.FormatAt != nil {
+		opts.arrival = clockOrDefault(w.Clock).Now()
+	}
+	if complete := w.prefixer.write(&w.buffer, p, opts); complete >= 0 {
+		// the prefixes are not searched for delimiters, they may contain line feeds themselves
+		w.complete = complete
+	}
+	if w.complete == 0 {
+		return len(p), nil
+	}
+	// p is consumed even if Writer fails, the lines that were not written are retried by the next Write
+	n, err := w.Writer.Write(w.buffer.Bytes()[:w.complete])
+	w.buffer.Next(n)
+	w.complete -= n
+	return len(p), err
+}
+
+// Flush writes a pending partial line to Writer.
+func (w *PrefixWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *PrefixWriter) flush() error {
+	w.prefixer.flush(&w.buffer, w.options())
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	_, err := w.Writer.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+	w.complete = 0
+	return err
+}
+
+// Close flushes a pending partial line, it does not close Writer.
+func (w *PrefixWriter) Close() error {
+	return w.Flush()
+}
+
+func (w *PrefixWriter) options() prefixOptions {
+	return prefixOptions{
+		format:          w.Format,
+		formatAt:        w.FormatAt,
+		colorCorrection: w.ColorCorrection,
+		carriageReturn:  w.CarriageReturn,
+		delimiter:       delimiterOrDefault(w.Delimiter),
+	}
+}
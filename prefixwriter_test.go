@@ -0,0 +1,336 @@
+package logtimer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	t.Run("Normal Usage", func(t *testing.T) {
+		var index int
+		var out bytes.Buffer
+		writer := &PrefixWriter{
+			Writer: &out,
+			Format: func() string {
+				defer func() {
+					index++
+				}()
+				return fmt.Sprintf("%d ", index)
+			},
+		}
+
+		for _, s := range []string{"Hello World\n", "Hello ", "Universe\n", "Hello\nRest", "\nTe\nst", "\n\n\n", "Foo", "Bar"} {
+			n, err := writer.Write([]byte(s))
+			require.NoError(t, err)
+			require.Equal(t, len(s), n)
+		}
+
+		require.Equal(t, "0 Hello World\n1 Hello Universe\n2 Hello\n3 Rest\n4 Te\n5 st\n6 \n7 \n", out.String())
+		require.NoError(t, writer.Close())
+		require.Equal(t, "0 Hello World\n1 Hello Universe\n2 Hello\n3 Rest\n4 Te\n5 st\n6 \n7 \n8 FooBar", out.String())
+	})
+
+	t.Run("Holds back partial lines", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &PrefixWriter{
+			Writer: &out,
+			Format: func() string {
+				return "> "
+			},
+		}
+
+		_, err := writer.Write([]byte("Hello"))
+		require.NoError(t, err)
+		require.Empty(t, out.String())
+
+		_, err = writer.Write([]byte(" World\nFoo"))
+		require.NoError(t, err)
+		require.Equal(t, "> Hello World\n", out.String())
+
+		require.NoError(t, writer.Flush())
+		require.Equal(t, "> Hello World\n> Foo", out.String())
+
+		require.NoError(t, writer.Flush())
+		require.Equal(t, "> Hello World\n> Foo", out.String())
+	})
+
+	t.Run("Line Feed In Prefix", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &PrefixWriter{
+			Writer: &out,
+			Format: func() string {
+				return "---\n> "
+			},
+		}
+
+		_, err := writer.Write([]byte("Hello"))
+		require.NoError(t, err)
+		require.Empty(t, out.String())
+		_, err = writer.Write([]byte(" World\nFoo"))
+		require.NoError(t, err)
+		require.Equal(t, "---\n> Hello World\n", out.String())
+	})
+
+	t.Run("Write Error", func(t *testing.T) {
+		out := &failingWriter{fail: true}
+		writer := &PrefixWriter{
+			Writer: out,
+			Format: func() string {
+				return "> "
+			},
+		}
+
+		n, err := writer.Write([]byte("Hello\nWor"))
+		require.ErrorIs(t, err, errWrite)
+		require.Equal(t, 9, n)
+
+		// the line that was not written is kept
+		out.fail = false
+		n, err = writer.Write([]byte("ld\n"))
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Equal(t, "> Hello\n> World\n", out.String())
+	})
+
+	t.Run("Color Correction", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &PrefixWriter{
+			Writer: &out,
+			Format: func() string {
+				return "> "
+			},
+			ColorCorrection: Enabled,
+		}
+
+		_, err := writer.Write([]byte("\x1b[31mRed\nStill Red\x1b[0m\n"))
+		require.NoError(t, err)
+		require.Equal(t, "\x1b[s\x1b[0m> \x1b[u\x1b[2C\x1b[31mRed\n\x1b[s\x1b[0m> \x1b[u\x1b[2CStill Red\x1b[0m\n", out.String())
+	})
+
+	t.Run("Color Correction Multibyte Prefixes", func(t *testing.T) {
+		tests := []struct {
+			prefix string
+			width  int
+		}{
+			{"[Do, 07.02.2019] ", 17},
+			{"[févr.] ", 8},
+			{"[木曜日 2019年02月07日] ", 24},
+			{"[🚀 +00:00:01] ", 15},
+			{"[e\u0301] ", 4},
+		}
+		for _, test := range tests {
+			t.Run(test.prefix, func(t *testing.T) {
+				var out bytes.Buffer
+				writer := &PrefixWriter{
+					Writer: &out,
+					Format: func() string {
+						return test.prefix
+					},
+					ColorCorrection: Enabled,
+				}
+
+				_, err := writer.Write([]byte("Hello\n"))
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf("\x1b[s\x1b[0m%s\x1b[u\x1b[%dCHello\n", test.prefix, test.width), out.String())
+			})
+		}
+	})
+
+	t.Run("Color Tracking", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			format   string
+			input    []string
+			expected string
+		}{
+			{
+				"Multiple Lines", "> ",
+				[]string{"\x1b[31mRed\nStill Red\x1b[0m\nPlain\n"},
+				"> \x1b[31mRed\n\x1b[0m> \x1b[31mStill Red\x1b[0m\n> Plain\n",
+			},
+			{
+				"Split Sequence", "> ",
+				[]string{"\x1b[1;3", "4mBold\n", "Blue\n"},
+				"> \x1b[1;34mBold\n\x1b[0m> \x1b[1;34mBlue\n",
+			},
+			{
+				"Colored Prefix", "\x1b[36m>\x1b[0m ",
+				[]string{"Plain\n\x1b[32mGreen\nGreen\n"},
+				"\x1b[36m>\x1b[0m \x1b[0mPlain\n\x1b[36m>\x1b[0m \x1b[0m\x1b[32mGreen\n" +
+					"\x1b[0m\x1b[36m>\x1b[0m \x1b[0m\x1b[32mGreen\n",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var out bytes.Buffer
+				writer := &PrefixWriter{
+					Writer: &out,
+					Format: func() string {
+						return test.format
+					},
+					ColorCorrection: Tracking,
+				}
+				for _, s := range test.input {
+					_, err := writer.Write([]byte(s))
+					require.NoError(t, err)
+				}
+				require.Equal(t, test.expected, out.String())
+			})
+		}
+	})
+
+	t.Run("Carriage Returns", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			mode     CarriageReturn
+			input    []string
+			expected string
+		}{
+			{"Ignore", IgnoreCarriageReturn, []string{"1%\r50%\r100%\n"}, "0 1%\r50%\r100%\n"},
+			{"Restart", RestartCarriageReturn, []string{"1%\r50%\r100%\n"}, "0 1%\r1 50%\r2 100%\n"},
+			{"Restart Split", RestartCarriageReturn, []string{"1%\r", "50%\r", "\nDone\n"}, "0 1%\r1 50%\r\n2 Done\n"},
+			{"Restart CRLF", RestartCarriageReturn, []string{"Hello\r\nWorld\r", "\n"}, "0 Hello\r\n1 World\r\n"},
+			{"Collapse", CollapseCarriageReturn, []string{"1%\r50%\r100%\nDone\n"}, "0 100%\n1 Done\n"},
+			{"Collapse Shorter Redraw", CollapseCarriageReturn, []string{"Downloading\rDone\n"}, "0 Doneloading\n"},
+			{"Collapse Split", CollapseCarriageReturn, []string{"1%\r", "50%", "\r100%\r", "\n"}, "0 100%\r\n"},
+			{"Collapse Multibyte", CollapseCarriageReturn, []string{"äöü\rx\n"}, "0 xöü\n"},
+			{"Collapse Escapes", CollapseCarriageReturn, []string{"\x1b[32m10%\r\x1b[32m1\n"}, "0 \x1b[32m1\n"},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var out bytes.Buffer
+				var index int
+				writer := &PrefixWriter{
+					Writer: &out,
+					Format: func() string {
+						defer func() {
+							index++
+						}()
+						return fmt.Sprintf("%d ", index)
+					},
+					CarriageReturn: test.mode,
+				}
+				for _, s := range test.input {
+					_, err := writer.Write([]byte(s))
+					require.NoError(t, err)
+				}
+				require.Equal(t, test.expected, out.String())
+			})
+		}
+
+		t.Run("Restart Passes Redraws", func(t *testing.T) {
+			var out bytes.Buffer
+			writer := &PrefixWriter{
+				Writer: &out,
+				Format: func() string {
+					return "> "
+				},
+				CarriageReturn: RestartCarriageReturn,
+			}
+			_, err := writer.Write([]byte("1%\r50%"))
+			require.NoError(t, err)
+			require.Equal(t, "> 1%\r", out.String())
+		})
+
+		t.Run("Collapse Flush", func(t *testing.T) {
+			var out bytes.Buffer
+			writer := &PrefixWriter{
+				Writer: &out,
+				Format: func() string {
+					return "> "
+				},
+				CarriageReturn: CollapseCarriageReturn,
+			}
+			_, err := writer.Write([]byte("1%\r50%\r"))
+			require.NoError(t, err)
+			require.Empty(t, out.String())
+			require.NoError(t, writer.Close())
+			require.Equal(t, "> 50%", out.String())
+		})
+	})
+
+	t.Run("Delimiter", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &PrefixWriter{
+			Writer: &out,
+			Format: func() string {
+				return "> "
+			},
+			Delimiter: []byte("\r\n"),
+		}
+
+		_, err := writer.Write([]byte("Hello\r"))
+		require.NoError(t, err)
+		require.Empty(t, out.String())
+		_, err = writer.Write([]byte("\nWorld\nand\r\nmore"))
+		require.NoError(t, err)
+		require.Equal(t, "> Hello\r\n> World\nand\r\n", out.String())
+		require.NoError(t, writer.Close())
+		require.Equal(t, "> Hello\r\n> World\nand\r\n> more", out.String())
+	})
+
+	t.Run("Delimiter Collapse", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &PrefixWriter{
+			Writer: &out,
+			Format: func() string {
+				return "> "
+			},
+			CarriageReturn: CollapseCarriageReturn,
+			Delimiter:      []byte{0},
+		}
+
+		_, err := writer.Write([]byte("1%\r100%\x00a\rb\r\x00"))
+		require.NoError(t, err)
+		require.Equal(t, "> 100%\x00> b\r\x00", out.String())
+	})
+
+	t.Run("Concurrent Writes", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &PrefixWriter{
+			Writer: &out,
+			Format: func() string {
+				return "> "
+			},
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_, _ = fmt.Fprintf(writer, "goroutine %d line %d\n", i, j)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		require.Len(t, lines, 1000)
+		for _, line := range lines {
+			require.Regexp(t, `^> goroutine \d+ line \d+$`, line)
+		}
+	})
+}
+
+var errWrite = errors.New("write failed")
+
+// failingWriter fails every Write while fail is set.
+type failingWriter struct {
+	bytes.Buffer
+	fail bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errWrite
+	}
+	return w.Buffer.Write(p)
+}
@@ -0,0 +1,54 @@
+package logtimer
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+var presets = map[string]string{
+	"ansic":       Layout(time.ANSIC),
+	"unixdate":    Layout(time.UnixDate),
+	"rfc822":      Layout(time.RFC822),
+	"rfc822z":     Layout(time.RFC822Z),
+	"rfc850":      Layout(time.RFC850),
+	"rfc1123":     Layout(time.RFC1123),
+	"rfc1123z":    Layout(time.RFC1123Z),
+	"rfc3339":     Layout(time.RFC3339),
+	"rfc3339nano": Layout(time.RFC3339Nano),
+	"iso8601":     Layout("2006-01-02T15:04:05-07:00"),
+	"kitchen":     Layout(time.Kitchen),
+	"syslog":      Layout(time.Stamp),
+	"stamp":       Layout(time.Stamp),
+	"stamp-milli": Layout(time.StampMilli),
+	"stamp-micro": Layout(time.StampMicro),
+	"stamp-nano":  Layout(time.StampNano),
+	"datetime":    Layout(time.DateTime),
+	"epoch":       "%s",
+	"epoch-ms":    "%s%L",
+	"epoch-us":    "%s%06f",
+	"epoch-ns":    "%s%N",
+}
+
+// Preset returns the format of a named preset, e.g. rfc3339nano, iso8601, kitchen, syslog, epoch-ms or stamp-micro.
+// See PresetNames for all presets.
+func Preset(name string) (string, bool) {
+	f, ok := presets[strings.ToLower(name)]
+	return f, ok
+}
+
+// PresetNames returns the names of all presets in alphabetical order.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Layout returns a format that formats the time with a Go reference layout, see time.Layout.
+// A layout must not contain a closing brace.
+func Layout(layout string) string {
+	return "%{" + LayoutNamespace + ":" + layout + "}"
+}
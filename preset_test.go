@@ -0,0 +1,60 @@
+package logtimer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreset(t *testing.T) {
+	tm := time.Date(2019, 2, 7, 9, 6, 5, 123456789, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"rfc3339", "2019-02-07T09:06:05+01:00"},
+		{"rfc3339nano", "2019-02-07T09:06:05.123456789+01:00"},
+		{"iso8601", "2019-02-07T09:06:05+01:00"},
+		{"kitchen", "9:06AM"},
+		{"syslog", "Feb  7 09:06:05"},
+		{"stamp-micro", "Feb  7 09:06:05.123456"},
+		{"epoch", "1549526765"},
+		{"epoch-ms", "1549526765123"},
+		{"epoch-us", "1549526765123456"},
+		{"epoch-ns", "1549526765123456789"},
+		{"RFC3339", "2019-02-07T09:06:05+01:00"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, ok := Preset(test.name)
+			require.True(t, ok)
+			require.NoError(t, ValidateTime(f))
+			require.Equal(t, test.expected, FormatTime(tm, f))
+		})
+	}
+
+	_, ok := Preset("unknown")
+	require.False(t, ok)
+
+	for _, name := range PresetNames() {
+		f, ok := Preset(name)
+		require.True(t, ok)
+		require.NoError(t, ValidateTime(f), name)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	tm := time.Date(2019, 2, 7, 9, 6, 5, 123456789, time.FixedZone("CET", 3600))
+	require.Equal(t, "[2019-02-07T09:06:05.123+01:00] ", FormatTime(tm, Layout("[2006-01-02T15:04:05.000Z07:00] ")))
+	require.Equal(t, "[08:06:05.123Z] %X", FormatTime(tm, "[%{layout@UTC:15:04:05.000Z07:00}] %%X"))
+	require.Equal(t, "[09:06:05 1549526765] ", FormatTime(tm, "[%{layout:15:04:05} %s] "))
+
+	c := Compiler{Location: time.UTC}
+	f, err := c.Compile(Layout("15:04:05 MST"))
+	require.NoError(t, err)
+	require.Equal(t, "08:06:05 UTC", f.Format(Stamp{Time: tm}))
+
+	require.ErrorIs(t, ValidateTime("%{layout:}"), ErrIncompleteDirective)
+	require.ErrorIs(t, ValidateTime("%{layout@Nowhere:15:04}"), ErrUnknownTimeZone)
+}
@@ -0,0 +1,33 @@
+package logtimer
+
+import (
+	"bytes"
+	"io"
+)
+
+// readProcessor writes what it makes of the bytes of a Read, and its error, to a readBuffer.
+type readProcessor interface {
+	process(data []byte, err error)
+}
+
+// readBuffer holds the output of PrefixReader, RecordReader and AsciicastReader.
+type readBuffer struct {
+	bytes.Buffer
+	err error
+}
+
+// read reads from r until processor has written output or r fails, the error is returned after the output.
+func (b *readBuffer) read(p []byte, r io.Reader, processor readProcessor) (int, error) {
+	for b.Len() == 0 && b.err == nil {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		n, err := r.Read(p)
+		processor.process(p[:n], err)
+		b.err = err
+	}
+	if b.Len() > 0 {
+		return b.Read(p)
+	}
+	return 0, b.err
+}
@@ -0,0 +1,122 @@
+package logtimer
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+// Record is a line and the Stamp of the time its first byte arrived.
+type Record struct {
+	Stamp
+	// Line excludes the delimiter.
+	Line []byte
+	// Delimiter is empty if the last line was not terminated.
+	Delimiter []byte
+	// Stream is e.g. stdout.
+	Stream string
+}
+
+// RecordEncoder encodes the lines that are read by RecordReader.
+type RecordEncoder interface {
+	// AppendRecord appends the encoding of r to dst.
+	AppendRecord(dst []byte, r Record) []byte
+}
+
+// RecordReader reads lines from Reader and returns them encoded by Encoder, e.g. as JSON Lines.
+// Unlike PrefixReader a line is only returned once it is complete.
+type RecordReader struct {
+	Encoder RecordEncoder
+	// Timer stamps the lines, if it is nil a Timer that starts with the first Read is used.
+	// Share a Timer between readers to get one sequence of lines, e.g. for the stdout and stderr of a command, the
+	// lines are stamped when they are read, see RecordWriter to stamp them in the order they are written.
+	Timer *Timer
+	// Stream is e.g. stdout.
+	Stream string
+	// CarriageReturn set to CollapseCarriageReturn stores the final state of a redrawn line.
+	CarriageReturn CarriageReturn
+	// Clock is used for the arrival time of the lines, if it is nil SystemClock is used.
+	Clock Clock
+	// Delimiter ends a line, e.g. []byte("\r\n") or []byte{0}, if it is empty a line feed is used.
+	Delimiter []byte
+	io.Reader
+
+	recorder recorder
+	buffer   readBuffer
+}
+
+func (rr *RecordReader) Read(p []byte) (int, error) {
+	if rr.Timer == nil {
+		rr.Timer = NewTimerWithClock(rr.Clock)
+	}
+	return rr.buffer.read(p, rr.Reader, rr)
+}
+
+func (rr *RecordReader) process(data []byte, err error) {
+	opts := recordOptions{
+		encoder:        rr.Encoder,
+		timer:          rr.Timer,
+		stream:         rr.Stream,
+		carriageReturn: rr.CarriageReturn,
+		delimiter:      delimiterOrDefault(rr.Delimiter),
+	}
+	if len(data) > 0 {
+		rr.recorder.write(&rr.buffer.Buffer, data, clockOrDefault(rr.Clock).Now(), opts)
+	}
+	if err == io.EOF {
+		rr.recorder.flush(&rr.buffer.Buffer, opts)
+	}
+}
+
+// recordOptions are the settings of RecordReader and RecordWriter that are used by the recorder.
+type recordOptions struct {
+	encoder        RecordEncoder
+	timer          *Timer
+	stream         string
+	carriageReturn CarriageReturn
+	delimiter      []byte
+}
+
+// recorder holds the current line of RecordReader and RecordWriter.
+type recorder struct {
+	matcher delimiterMatcher
+	line    []byte
+	arrival time.Time
+}
+
+// write adds p, which arrived at arrival, to the current line and encodes every line that is complete to buf.
+func (rc *recorder) write(buf *bytes.Buffer, p []byte, arrival time.Time, opts recordOptions) {
+	for _, b := range p {
+		if len(rc.line) == 0 {
+			rc.arrival = arrival
+		}
+		rc.line = append(rc.line, b)
+		if rc.matcher.match(b, opts.delimiter) {
+			rc.writeRecord(buf, opts.delimiter, opts)
+		}
+	}
+}
+
+// flush encodes a line that was not terminated to buf.
+func (rc *recorder) flush(buf *bytes.Buffer, opts recordOptions) {
+	if len(rc.line) > 0 {
+		rc.writeRecord(buf, nil, opts)
+	}
+}
+
+// writeRecord encodes the current line, which ends with delimiter.
+func (rc *recorder) writeRecord(buf *bytes.Buffer, delimiter []byte, opts recordOptions) {
+	line := rc.line[:len(rc.line)-len(delimiter)]
+	if opts.carriageReturn == CollapseCarriageReturn {
+		// see prefixer.writeLine
+		line = collapse(bytes.TrimSuffix(line, []byte{'\r'}))
+	}
+	r := Record{
+		Stamp:     opts.timer.NextAt(rc.arrival),
+		Line:      line,
+		Delimiter: delimiter,
+		Stream:    opts.stream,
+	}
+	_, _ = buf.Write(opts.encoder.AppendRecord(buf.AvailableBuffer(), r))
+	rc.line = rc.line[:0]
+}
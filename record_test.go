@@ -0,0 +1,97 @@
+package logtimer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// lineEncoder encodes a Record as seq, elapsed seconds and the quoted line.
+type lineEncoder struct{}
+
+func (lineEncoder) AppendRecord(dst []byte, r Record) []byte {
+	return fmt.Appendf(dst, "%d %.0f %q %q\n", r.Seq, r.Elapsed().Seconds(), r.Line, r.Delimiter)
+}
+
+func TestRecordReader(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+
+	t.Run("Arrival Time", func(t *testing.T) {
+		in := make(chan string)
+		reader := &RecordReader{
+			Reader:  &chanReader{c: in},
+			Encoder: lineEncoder{},
+			Clock:   NewFakeClock(start, time.Second),
+		}
+		go func() {
+			in <- "Hello\n"
+			in <- "World\nand"
+			in <- " more\nunterminated"
+			close(in)
+		}()
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		// the Timer starts with the first Read, a line is stamped with the time its first byte arrived
+		require.Equal(t, "1 1 \"Hello\" \"\\n\"\n2 2 \"World\" \"\\n\"\n3 2 \"and more\" \"\\n\"\n"+
+			"4 3 \"unterminated\" \"\"\n", string(out))
+	})
+
+	t.Run("Data with Error", func(t *testing.T) {
+		errRead := errors.New("read failed")
+		reader := &RecordReader{
+			Reader:  &errReader{data: []byte("Hello\nWor"), err: errRead},
+			Encoder: lineEncoder{},
+			Clock:   NewFakeClock(start, 0),
+		}
+		out, err := io.ReadAll(reader)
+		require.ErrorIs(t, err, errRead)
+		// the incomplete line is not encoded, the reader did not end
+		require.Equal(t, "1 0 \"Hello\" \"\\n\"\n", string(out))
+	})
+
+	t.Run("Delimiter", func(t *testing.T) {
+		for _, size := range []int{1, 2, 3, 64} {
+			reader := &RecordReader{
+				Reader:    &chunkReader{data: []byte("a\r\nb\rc\r\n\r\n"), size: size},
+				Encoder:   lineEncoder{},
+				Clock:     NewFakeClock(start, 0),
+				Delimiter: []byte("\r\n"),
+			}
+			out, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			require.Equal(t, "1 0 \"a\" \"\\r\\n\"\n2 0 \"b\\rc\" \"\\r\\n\"\n3 0 \"\" \"\\r\\n\"\n", string(out), "size %d", size)
+		}
+	})
+
+	t.Run("Collapse Carriage Return", func(t *testing.T) {
+		reader := &RecordReader{
+			Reader:         strings.NewReader("10%\r50%\r100%\r\ndone\n"),
+			Encoder:        lineEncoder{},
+			Clock:          NewFakeClock(start, 0),
+			CarriageReturn: CollapseCarriageReturn,
+		}
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, "1 0 \"100%\" \"\\n\"\n2 0 \"done\" \"\\n\"\n", string(out))
+	})
+
+	t.Run("Shared Timer", func(t *testing.T) {
+		clock := NewFakeClock(start, time.Second)
+		timer := NewTimerWithClock(clock)
+		stdout := &RecordReader{Reader: strings.NewReader("out\n"), Encoder: JSONEncoder{}, Timer: timer, Clock: clock,
+			Stream: "stdout"}
+		stderr := &RecordReader{Reader: strings.NewReader("err\n"), Encoder: JSONEncoder{}, Timer: timer, Clock: clock,
+			Stream: "stderr"}
+		out, err := io.ReadAll(io.MultiReader(stdout, stderr))
+		require.NoError(t, err)
+		require.Equal(t,
+			`{"ts":"2019-02-07T11:26:46Z","elapsed_ns":1000000000,"delta_ns":1000000000,"line":"out","stream":"stdout","seq":1}`+"\n"+
+				`{"ts":"2019-02-07T11:26:47Z","elapsed_ns":2000000000,"delta_ns":1000000000,"line":"err","stream":"stderr","seq":2}`+"\n",
+			string(out))
+	})
+}
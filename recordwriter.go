@@ -0,0 +1,73 @@
+package logtimer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// RecordWriter is the io.Writer counterpart of RecordReader, it encodes the lines that are written to it and writes
+// them to Writer.
+//
+// The records of a Write are passed to Writer in a single Write call, a trailing partial line is held back until it
+// is completed or Close is called. A line is stamped when it is complete, so writers that share a Timer keep their
+// records in order if their writes are serialized, e.g. for the stdout and stderr of a command.
+type RecordWriter struct {
+	Encoder RecordEncoder
+	// Timer stamps the lines, if it is nil a Timer that starts with the first Write is used.
+	Timer *Timer
+	// Stream is e.g. stdout.
+	Stream string
+	// CarriageReturn set to CollapseCarriageReturn stores the final state of a redrawn line.
+	CarriageReturn CarriageReturn
+	// Clock is used for the arrival time of the lines, if it is nil SystemClock is used.
+	Clock Clock
+	// Delimiter ends a line, e.g. []byte("\r\n") or []byte{0}, if it is empty a line feed is used.
+	Delimiter []byte
+	io.Writer
+
+	mu       sync.Mutex
+	recorder recorder
+	buffer   bytes.Buffer
+}
+
+func (w *RecordWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	opts := w.options()
+	if len(p) > 0 {
+		w.recorder.write(&w.buffer, p, clockOrDefault(w.Clock).Now(), opts)
+	}
+	return len(p), w.writeBuffer()
+}
+
+// Close encodes a pending partial line, it does not close Writer.
+func (w *RecordWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.recorder.flush(&w.buffer, w.options())
+	return w.writeBuffer()
+}
+
+// writeBuffer writes the encoded records to Writer, the records that were not written are kept.
+func (w *RecordWriter) writeBuffer() error {
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	n, err := w.Writer.Write(w.buffer.Bytes())
+	w.buffer.Next(n)
+	return err
+}
+
+func (w *RecordWriter) options() recordOptions {
+	if w.Timer == nil {
+		w.Timer = NewTimerWithClock(w.Clock)
+	}
+	return recordOptions{
+		encoder:        w.Encoder,
+		timer:          w.Timer,
+		stream:         w.Stream,
+		carriageReturn: w.CarriageReturn,
+		delimiter:      delimiterOrDefault(w.Delimiter),
+	}
+}
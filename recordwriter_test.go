@@ -0,0 +1,69 @@
+package logtimer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordWriter(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+
+	t.Run("Arrival Time", func(t *testing.T) {
+		var out bytes.Buffer
+		writer := &RecordWriter{
+			Writer:  &out,
+			Encoder: lineEncoder{},
+			Clock:   NewFakeClock(start, time.Second),
+		}
+		for _, s := range []string{"Hello\n", "World\nand", " more\nunterminated"} {
+			n, err := writer.Write([]byte(s))
+			require.NoError(t, err)
+			require.Equal(t, len(s), n)
+		}
+		// the Timer starts with the first Write, a line is stamped with the time its first byte was written
+		require.Equal(t, "1 1 \"Hello\" \"\\n\"\n2 2 \"World\" \"\\n\"\n3 2 \"and more\" \"\\n\"\n", out.String())
+
+		require.NoError(t, writer.Close())
+		require.Equal(t, "1 1 \"Hello\" \"\\n\"\n2 2 \"World\" \"\\n\"\n3 2 \"and more\" \"\\n\"\n"+
+			"4 3 \"unterminated\" \"\"\n", out.String())
+	})
+
+	t.Run("Write Error", func(t *testing.T) {
+		out := &failingWriter{fail: true}
+		writer := &RecordWriter{
+			Writer:  out,
+			Encoder: lineEncoder{},
+			Clock:   NewFakeClock(start, 0),
+		}
+
+		n, err := writer.Write([]byte("Hello\nWor"))
+		require.ErrorIs(t, err, errWrite)
+		require.Equal(t, 9, n)
+
+		// the record that was not written is kept
+		out.fail = false
+		n, err = writer.Write([]byte("ld\n"))
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Equal(t, "1 0 \"Hello\" \"\\n\"\n2 0 \"World\" \"\\n\"\n", out.String())
+	})
+
+	t.Run("Shared Timer", func(t *testing.T) {
+		clock := NewFakeClock(start, time.Second)
+		timer := NewTimerWithClock(clock)
+		var out bytes.Buffer
+		stdout := &RecordWriter{Writer: &out, Encoder: JSONEncoder{}, Timer: timer, Clock: clock, Stream: "stdout"}
+		stderr := &RecordWriter{Writer: &out, Encoder: JSONEncoder{}, Timer: timer, Clock: clock, Stream: "stderr"}
+		// the partial line of stdout is stamped when its first byte is written, but encoded after the line of stderr
+		_, _ = stdout.Write([]byte("ou"))
+		_, _ = stderr.Write([]byte("err\n"))
+		_, _ = stdout.Write([]byte("t\n"))
+		require.Equal(t,
+			`{"ts":"2019-02-07T11:26:47Z","elapsed_ns":2000000000,"delta_ns":2000000000,"line":"err","stream":"stderr","seq":1}`+"\n"+
+				`{"ts":"2019-02-07T11:26:47Z","elapsed_ns":2000000000,"delta_ns":0,"line":"out","stream":"stdout","seq":2}`+"\n",
+			out.String())
+	})
+}
@@ -0,0 +1,204 @@
+package logtimer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReplayEvent is output of a recording and the time it was written, relative to the start of the recording.
+type ReplayEvent struct {
+	Elapsed time.Duration
+	Data    []byte
+}
+
+// EventDecoder reads the events of a timed log, either JSON Lines written by JSONEncoder or an asciicast v2
+// recording written by AsciicastReader. The format is detected by the first line that is not empty.
+type EventDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+	// started is set after the first line that is not empty, only that line can be the header of an asciicast
+	started bool
+	// asciicast is set after the header of an asciicast has been read
+	asciicast bool
+}
+
+// NewEventDecoder returns an EventDecoder that reads from r.
+func NewEventDecoder(r io.Reader) *EventDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	return &EventDecoder{scanner: scanner}
+}
+
+// jsonLine holds the fields of a line written by JSONEncoder that are replayed.
+type jsonLine struct {
+	ElapsedNS *int64  `json:"elapsed_ns"`
+	Line      string  `json:"line"`
+	Raw       *string `json:"raw"`
+}
+
+// Next returns the next event, at the end of the log it returns io.EOF.
+func (d *EventDecoder) Next() (ReplayEvent, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		first := !d.started
+		d.started = true
+		if first && line[0] == '{' && bytes.Contains(line, []byte(`"version"`)) {
+			var header struct {
+				Version int `json:"version"`
+			}
+			if err := json.Unmarshal(line, &header); err == nil && header.Version == 2 {
+				d.asciicast = true
+				continue
+			}
+		}
+		event, ok, err := d.decode(line)
+		if err != nil {
+			return ReplayEvent{}, fmt.Errorf("line %d: %w", d.line, err)
+		}
+		if ok {
+			return event, nil
+		}
+	}
+	if err := d.scanner.Err(); err != nil {
+		return ReplayEvent{}, err
+	}
+	return ReplayEvent{}, io.EOF
+}
+
+// decode decodes an event, ok is false for events that are not replayed, e.g. the input events of an asciicast.
+func (d *EventDecoder) decode(line []byte) (event ReplayEvent, ok bool, err error) {
+	if d.asciicast {
+		var fields []json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil || len(fields) != 3 {
+			return ReplayEvent{}, false, errors.New("invalid asciicast event")
+		}
+		var seconds float64
+		var code, data string
+		if json.Unmarshal(fields[0], &seconds) != nil || json.Unmarshal(fields[1], &code) != nil ||
+			json.Unmarshal(fields[2], &data) != nil {
+			return ReplayEvent{}, false, errors.New("invalid asciicast event")
+		}
+		if code != "o" {
+			return ReplayEvent{}, false, nil
+		}
+		return ReplayEvent{Elapsed: time.Duration(seconds * float64(time.Second)), Data: []byte(data)}, true, nil
+	}
+
+	var l jsonLine
+	if err := json.Unmarshal(line, &l); err != nil || l.ElapsedNS == nil {
+		return ReplayEvent{}, false, errors.New("neither a JSON line with elapsed_ns nor an asciicast")
+	}
+	data := l.Line
+	if l.Raw != nil {
+		// the line was written with SeparateANSI, the raw line keeps the colors
+		data = *l.Raw
+	}
+	return ReplayEvent{Elapsed: time.Duration(*l.ElapsedNS), Data: []byte(data + "\n")}, true, nil
+}
+
+// Keys that control a Replayer.
+const (
+	// PauseKey pauses and resumes the replay.
+	PauseKey = ' '
+	// StepKey writes the next event without waiting, while paused it writes one event.
+	StepKey = '.'
+	// QuitKey stops the replay.
+	QuitKey = 'q'
+)
+
+// Replayer writes the events of a timed log with their original timing.
+type Replayer struct {
+	// Speed scales the time between events, e.g. 4 plays four times as fast. If it is 0 the original speed is used.
+	Speed float64
+	// MaxIdle caps the time between events, after scaling it by Speed. If it is 0 the time is not capped.
+	MaxIdle time.Duration
+	// Keys receives the key presses that control the replay, see PauseKey, StepKey and QuitKey. It may be nil.
+	Keys <-chan byte
+	// After waits for the duration, if it is nil time.After is used.
+	After func(d time.Duration) <-chan time.Time
+}
+
+// Delay returns the time to wait between an event at previous and the next event at next.
+func (r *Replayer) Delay(previous, next time.Duration) time.Duration {
+	d := next - previous
+	if d < 0 {
+		return 0
+	}
+	if r.Speed > 0 {
+		d = time.Duration(float64(d) / r.Speed)
+	}
+	if r.MaxIdle > 0 && d > r.MaxIdle {
+		return r.MaxIdle
+	}
+	return d
+}
+
+// Replay writes the events of d to w until the end of the log or until QuitKey is pressed.
+func (r *Replayer) Replay(w io.Writer, d *EventDecoder) error {
+	after := r.After
+	if after == nil {
+		after = time.After
+	}
+	keys := r.Keys
+	var previous time.Duration
+	paused := false
+	for {
+		event, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		wait := after(r.Delay(previous, event.Elapsed))
+		if paused {
+			wait = nil
+		}
+		for waiting := true; waiting; {
+			select {
+			case <-wait:
+				waiting = false
+			case key, ok := <-keys:
+				if !ok {
+					// without keys the replay cannot be resumed
+					keys = nil
+					if paused {
+						paused, waiting = false, false
+					}
+					continue
+				}
+				switch key {
+				case QuitKey:
+					return nil
+				case StepKey:
+					waiting = false
+				case PauseKey:
+					paused = !paused
+					if paused {
+						wait = nil
+					} else {
+						// resuming writes the next event, its delay has passed while paused
+						waiting = false
+					}
+				}
+			}
+		}
+		// logtimer writes events in order, but files of other tools or edited by hand may not, events that are out of
+		// order are written without waiting and do not move the replay back in time
+		previous = max(previous, event.Elapsed)
+
+		if _, err := w.Write(event.Data); err != nil {
+			return err
+		}
+	}
+}
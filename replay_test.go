@@ -0,0 +1,171 @@
+package logtimer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readEvents returns all events of the log.
+func readEvents(t *testing.T, log string) []ReplayEvent {
+	d := NewEventDecoder(strings.NewReader(log))
+	var events []ReplayEvent
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			return events
+		}
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+}
+
+func TestEventDecoder(t *testing.T) {
+	t.Run("JSON Lines", func(t *testing.T) {
+		events := readEvents(t, `{"ts":"2019-02-07T11:26:46.5Z","elapsed_ns":1500000000,"line":"Hello","seq":1}`+"\n\n"+
+			`{"elapsed_ns":3000000000,"line":"red","raw":"\u001b[31mred\u001b[0m","seq":2}`+"\n")
+		require.Equal(t, []ReplayEvent{
+			{Elapsed: 1500 * time.Millisecond, Data: []byte("Hello\n")},
+			{Elapsed: 3 * time.Second, Data: []byte("\x1b[31mred\x1b[0m\n")},
+		}, events)
+	})
+
+	t.Run("Asciicast", func(t *testing.T) {
+		events := readEvents(t, `{"version":2,"width":80,"height":24}`+"\n"+
+			`[1.500000, "o", "Hello\r\n"]`+"\n"+
+			`[2.000000, "i", "q"]`+"\n"+
+			`[3.250000, "o", "World"]`+"\n")
+		require.Equal(t, []ReplayEvent{
+			{Elapsed: 1500 * time.Millisecond, Data: []byte("Hello\r\n")},
+			{Elapsed: 3250 * time.Millisecond, Data: []byte("World")},
+		}, events)
+	})
+
+	t.Run("Asciicast After Blank Lines", func(t *testing.T) {
+		events := readEvents(t, "\n  \n"+`{"version":2,"width":80,"height":24}`+"\n"+`[1.500000, "o", "Hello"]`+"\n")
+		require.Equal(t, []ReplayEvent{{Elapsed: 1500 * time.Millisecond, Data: []byte("Hello")}}, events)
+	})
+
+	t.Run("Recorded", func(t *testing.T) {
+		start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+		header, err := AsciicastHeader{Width: 80, Height: 24, Timestamp: start}.AppendJSON(nil)
+		require.NoError(t, err)
+		cast, err := io.ReadAll(&AsciicastReader{
+			Reader: &chunkReader{data: []byte("Hello\nWorld\n"), size: 6},
+			Clock:  NewFakeClock(start, time.Second),
+			CRLF:   true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []ReplayEvent{
+			{Elapsed: time.Second, Data: []byte("Hello\r\n")},
+			{Elapsed: 2 * time.Second, Data: []byte("World\r\n")},
+		}, readEvents(t, string(header)+string(cast)))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, log := range []string{"plain text\n", `{"line":"no time"}` + "\n", `{"version":2}` + "\n" + `[1, "o"]` + "\n"} {
+			_, err := NewEventDecoder(strings.NewReader(log)).Next()
+			require.Error(t, err, log)
+			require.NotErrorIs(t, err, io.EOF)
+		}
+	})
+}
+
+func TestReplayerDelay(t *testing.T) {
+	tests := []struct {
+		replayer Replayer
+		previous time.Duration
+		next     time.Duration
+		expected time.Duration
+	}{
+		{Replayer{}, time.Second, 3 * time.Second, 2 * time.Second},
+		{Replayer{Speed: 4}, time.Second, 3 * time.Second, 500 * time.Millisecond},
+		{Replayer{Speed: 0.5}, time.Second, 3 * time.Second, 4 * time.Second},
+		{Replayer{MaxIdle: time.Second}, 0, time.Minute, time.Second},
+		{Replayer{Speed: 2, MaxIdle: 2 * time.Second}, 0, 3 * time.Second, 1500 * time.Millisecond},
+		{Replayer{}, 3 * time.Second, time.Second, 0},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, test.replayer.Delay(test.previous, test.next), "%+v", test)
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	const log = `{"elapsed_ns":1000000000,"line":"a"}` + "\n" +
+		`{"elapsed_ns":3000000000,"line":"b"}` + "\n" +
+		`{"elapsed_ns":7000000000,"line":"c"}` + "\n"
+
+	t.Run("Timing", func(t *testing.T) {
+		var delays []time.Duration
+		r := Replayer{
+			Speed:   2,
+			MaxIdle: 1500 * time.Millisecond,
+			After: func(d time.Duration) <-chan time.Time {
+				delays = append(delays, d)
+				c := make(chan time.Time, 1)
+				c <- time.Time{}
+				return c
+			},
+		}
+		var out bytes.Buffer
+		require.NoError(t, r.Replay(&out, NewEventDecoder(strings.NewReader(log))))
+		require.Equal(t, "a\nb\nc\n", out.String())
+		require.Equal(t, []time.Duration{500 * time.Millisecond, time.Second, 1500 * time.Millisecond}, delays)
+	})
+
+	t.Run("Out of Order", func(t *testing.T) {
+		// logtimer does not write events out of order, but other tools might
+		var delays []time.Duration
+		r := Replayer{
+			After: func(d time.Duration) <-chan time.Time {
+				delays = append(delays, d)
+				c := make(chan time.Time, 1)
+				c <- time.Time{}
+				return c
+			},
+		}
+		var out bytes.Buffer
+		require.NoError(t, r.Replay(&out, NewEventDecoder(strings.NewReader(
+			`{"elapsed_ns":3000000000,"line":"a"}`+"\n"+
+				`{"elapsed_ns":1000000000,"line":"b"}`+"\n"+
+				`{"elapsed_ns":4000000000,"line":"c"}`+"\n"))))
+		require.Equal(t, "a\nb\nc\n", out.String())
+		// the event that is out of order does not add the time back to the next event
+		require.Equal(t, []time.Duration{3 * time.Second, 0, time.Second}, delays)
+	})
+
+	t.Run("Keys", func(t *testing.T) {
+		keys := make(chan byte)
+		// the delays never pass, only the keys advance the replay
+		r := Replayer{
+			Keys: keys,
+			After: func(time.Duration) <-chan time.Time {
+				return nil
+			},
+		}
+		var out bytes.Buffer
+		done := make(chan error)
+		go func() {
+			done <- r.Replay(&out, NewEventDecoder(strings.NewReader(log)))
+		}()
+		keys <- PauseKey
+		keys <- StepKey // writes a, still paused
+		keys <- PauseKey
+		keys <- QuitKey
+		require.NoError(t, <-done)
+		require.Equal(t, "a\nb\n", out.String())
+	})
+
+	t.Run("Closed Keys", func(t *testing.T) {
+		keys := make(chan byte)
+		close(keys)
+		var out bytes.Buffer
+		r := Replayer{Keys: keys, MaxIdle: time.Millisecond}
+		require.NoError(t, r.Replay(&out, NewEventDecoder(strings.NewReader(log))))
+		require.Equal(t, "a\nb\nc\n", out.String())
+	})
+}
@@ -0,0 +1,188 @@
+package logtimer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxSGRLength limits the parameters of an escape sequence that are kept, longer sequences are ignored.
+const maxSGRLength = 64
+
+// sgrTracker follows the SGR (Select Graphic Rendition) escape sequences of a stream, so the active
+// attributes can be re-applied after a prefix.
+type sgrTracker struct {
+	attributes [10]bool // bold (1) to crossed out (9)
+	foreground string
+	background string
+	underline  string
+
+	// state of the escape sequence parser, sequences can be split across writes
+	state        int
+	params       []byte
+	intermediate bool
+}
+
+const (
+	sgrText = iota
+	sgrEscape
+	sgrCSI
+)
+
+// feed advances the tracker by one byte of the stream.
+func (t *sgrTracker) feed(b byte) {
+	switch t.state {
+	case sgrText:
+		if b == 0x1b {
+			t.state = sgrEscape
+		}
+	case sgrEscape:
+		switch b {
+		case '[':
+			t.state = sgrCSI
+			t.params = t.params[:0]
+			t.intermediate = false
+		case 0x1b:
+		default:
+			t.state = sgrText
+		}
+	case sgrCSI:
+		t.feedCSI(b)
+	}
+}
+
+func (t *sgrTracker) feedCSI(b byte) {
+	switch {
+	case b >= 0x30 && b <= 0x3f: // parameter bytes
+		if len(t.params) >= maxSGRLength {
+			t.state = sgrText
+			return
+		}
+		t.params = append(t.params, b)
+	case b >= 0x20 && b <= 0x2f: // intermediate bytes
+		t.intermediate = true
+	case b >= 0x40 && b <= 0x7e: // final byte
+		if b == 'm' && !t.intermediate {
+			t.apply(string(t.params))
+		}
+		t.state = sgrText
+	case b == 0x1b:
+		t.state = sgrEscape
+	default:
+		t.state = sgrText
+	}
+}
+
+// apply applies the parameters of an SGR sequence.
+func (t *sgrTracker) apply(params string) {
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if code, _, ok := strings.Cut(field, ":"); ok {
+			// colon separated sub parameters, e.g. 38:5:208 or 4:3
+			t.applyColor(code, field)
+			if code == "4" {
+				t.attributes[4] = !strings.HasSuffix(field, ":0")
+			}
+			continue
+		}
+		if field == "" {
+			t.reset()
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			t.reset()
+		case n >= 1 && n <= 9:
+			t.attributes[n] = true
+		case n == 22:
+			t.attributes[1] = false
+			t.attributes[2] = false
+		case n == 25:
+			t.attributes[5] = false
+			t.attributes[6] = false
+		case n >= 23 && n <= 29:
+			t.attributes[n-20] = false
+		case n == 38 || n == 48 || n == 58:
+			color, consumed := extendedColor(fields[i:])
+			t.applyColor(field, color)
+			i += consumed - 1
+		default:
+			t.applyColor(field, field)
+		}
+	}
+}
+
+// applyColor sets or resets the color that code selects, the color is the full parameter of the code.
+func (t *sgrTracker) applyColor(code, color string) {
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return
+	}
+	switch {
+	case n >= 30 && n <= 38, n >= 90 && n <= 97:
+		t.foreground = color
+	case n == 39:
+		t.foreground = ""
+	case n >= 40 && n <= 48, n >= 100 && n <= 107:
+		t.background = color
+	case n == 49:
+		t.background = ""
+	case n == 58:
+		t.underline = color
+	case n == 59:
+		t.underline = ""
+	}
+}
+
+// extendedColor returns the color of a 38, 48 or 58 parameter in the form 5;n or 2;r;g;b and the number of
+// fields it is made of. For a malformed color an empty color is returned that consumes all fields.
+func extendedColor(fields []string) (string, int) {
+	switch {
+	case len(fields) >= 3 && fields[1] == "5":
+		return strings.Join(fields[:3], ";"), 3
+	case len(fields) >= 5 && fields[1] == "2":
+		return strings.Join(fields[:5], ";"), 5
+	default:
+		return "", len(fields)
+	}
+}
+
+func (t *sgrTracker) reset() {
+	t.attributes = [10]bool{}
+	t.foreground = ""
+	t.background = ""
+	t.underline = ""
+}
+
+// active reports whether any attribute is set.
+func (t *sgrTracker) active() bool {
+	return t.attributes != [10]bool{} || t.foreground != "" || t.background != "" || t.underline != ""
+}
+
+// appendSequence appends an SGR sequence that sets the active attributes.
+func (t *sgrTracker) appendSequence(dst []byte) []byte {
+	dst = append(dst, "\x1b["...)
+	sep := false
+	add := func(param string) {
+		if sep {
+			dst = append(dst, ';')
+		}
+		dst = append(dst, param...)
+		sep = true
+	}
+	for n, set := range t.attributes {
+		if set {
+			add(strconv.Itoa(n))
+		}
+	}
+	for _, color := range []string{t.foreground, t.background, t.underline} {
+		if color != "" {
+			add(color)
+		}
+	}
+	return append(dst, 'm')
+}
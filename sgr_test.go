@@ -0,0 +1,44 @@
+package logtimer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSGRTracker(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		active   bool
+		sequence string
+	}{
+		{"Plain Text", "Hello", false, ""},
+		{"Foreground", "\x1b[31mRed", true, "\x1b[31m"},
+		{"Reset", "\x1b[31mRed\x1b[0m", false, ""},
+		{"Empty Reset", "\x1b[1;31mRed\x1b[m", false, ""},
+		{"Attributes", "\x1b[1m\x1b[4;3m\x1b[23m", true, "\x1b[1;4m"},
+		{"Normal Intensity", "\x1b[1;2;34m\x1b[22m", true, "\x1b[34m"},
+		{"Default Colors", "\x1b[31;42m\x1b[39m", true, "\x1b[42m"},
+		{"Bright Colors", "\x1b[91;103m", true, "\x1b[91;103m"},
+		{"256 Colors", "\x1b[1;38;5;208;48;5;17m", true, "\x1b[1;38;5;208;48;5;17m"},
+		{"Truecolor", "\x1b[38;2;255;128;0m", true, "\x1b[38;2;255;128;0m"},
+		{"Sub Parameters", "\x1b[38:2::255:128:0;4:3m", true, "\x1b[4;38:2::255:128:0m"},
+		{"Underline Off", "\x1b[4:3m\x1b[4:0m", false, ""},
+		{"Underline Color", "\x1b[58;5;1m", true, "\x1b[58;5;1m"},
+		{"Other Sequences", "\x1b[2K\x1b[1A\x1b]0;title\x07", false, ""},
+		{"Intermediate Bytes", "\x1b[1 m", false, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var tracker sgrTracker
+			for i := 0; i < len(test.input); i++ {
+				tracker.feed(test.input[i])
+			}
+			require.Equal(t, test.active, tracker.active())
+			if test.active {
+				require.Equal(t, test.sequence, string(tracker.appendSequence(nil)))
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package logtimer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Namespaces of the styling directives, they set the color of the following text.
+const (
+	// ForegroundNamespace sets the text color, e.g. %{fg:cyan}, %{fg:208} or %{fg:#ff8000}.
+	ForegroundNamespace = "fg"
+	// BackgroundNamespace sets the background color, e.g. %{bg:blue}, %{bg:17} or %{bg:#00005f}.
+	BackgroundNamespace = "bg"
+)
+
+// styles are the styling directives that are used without a namespace, e.g. %{bold}.
+var styles = map[string]string{
+	"reset":     "\x1b[0m",
+	"bold":      "\x1b[1m",
+	"dim":       "\x1b[2m",
+	"italic":    "\x1b[3m",
+	"underline": "\x1b[4m",
+	"blink":     "\x1b[5m",
+	"reverse":   "\x1b[7m",
+	"hidden":    "\x1b[8m",
+	"strike":    "\x1b[9m",
+}
+
+// colors are the offsets of the named colors, to the foreground (30) or background (40) code.
+var colors = map[string]int{
+	"black":          0,
+	"red":            1,
+	"green":          2,
+	"yellow":         3,
+	"blue":           4,
+	"magenta":        5,
+	"cyan":           6,
+	"white":          7,
+	"default":        9,
+	"gray":           60,
+	"bright-black":   60,
+	"bright-red":     61,
+	"bright-green":   62,
+	"bright-yellow":  63,
+	"bright-blue":    64,
+	"bright-magenta": 65,
+	"bright-cyan":    66,
+	"bright-white":   67,
+}
+
+// ColorNames returns the names of the colors that can be used with ForegroundNamespace and BackgroundNamespace
+// in alphabetical order. Besides the names a color can be a number of the 256 color palette (0-255) or a
+// #rrggbb truecolor.
+func ColorNames() []string {
+	names := make([]string, 0, len(colors))
+	for name := range colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colorSequence returns the escape sequence that sets color in the foreground or background namespace.
+func colorSequence(namespace, color string) (string, bool) {
+	base := 30
+	if namespace == BackgroundNamespace {
+		base = 40
+	}
+	if offset, ok := colors[strings.ToLower(color)]; ok {
+		return "\x1b[" + strconv.Itoa(base+offset) + "m", true
+	}
+	if rgb, ok := strings.CutPrefix(color, "#"); ok {
+		if len(rgb) != 6 {
+			return "", false
+		}
+		v, err := strconv.ParseUint(rgb, 16, 32)
+		if err != nil {
+			return "", false
+		}
+		return "\x1b[" + strconv.Itoa(base+8) + ";2;" + strconv.Itoa(int(v>>16)) + ";" +
+			strconv.Itoa(int(v>>8&0xff)) + ";" + strconv.Itoa(int(v&0xff)) + "m", true
+	}
+	n, err := strconv.ParseUint(color, 10, 8)
+	if err != nil {
+		return "", false
+	}
+	return "\x1b[" + strconv.Itoa(base+8) + ";5;" + strconv.Itoa(int(n)) + "m", true
+}
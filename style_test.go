@@ -0,0 +1,52 @@
+package logtimer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStyles(t *testing.T) {
+	s := Stamp{Time: time.Date(2019, 2, 7, 11, 29, 57, 0, time.UTC)}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%{fg:cyan}[%X]%{reset} ", "\x1b[36m[11:29:57]\x1b[0m "},
+		{"%{dim}%X", "\x1b[2m11:29:57"},
+		{"%{bold}%{underline}%{italic}%{strike}%{reverse}%{blink}%{hidden}", "\x1b[1m\x1b[4m\x1b[3m\x1b[9m\x1b[7m\x1b[5m\x1b[8m"},
+		{"%{fg:bright-red}%{bg:gray}%{fg:default}%{bg:default}", "\x1b[91m\x1b[100m\x1b[39m\x1b[49m"},
+		{"%{fg:RED}%{bg:black}", "\x1b[31m\x1b[40m"},
+		{"%{fg:208}%{bg:0}", "\x1b[38;5;208m\x1b[48;5;0m"},
+		{"%{fg:#ff8000}%{bg:#00005F}", "\x1b[38;2;255;128;0m\x1b[48;2;0;0;95m"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			f, err := Compile(test.format)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, f.Format(s))
+		})
+	}
+
+	t.Run("NoStyles", func(t *testing.T) {
+		c := Compiler{NoStyles: true}
+		f, err := c.Compile("%{fg:cyan}[%X]%{reset} %{bold}%{bg:#ff8000}x")
+		require.NoError(t, err)
+		require.Equal(t, "[11:29:57] x", f.Format(s))
+	})
+
+	t.Run("Invalid Colors", func(t *testing.T) {
+		for _, format := range []string{"%{fg:nope}", "%{bg:256}", "%{fg:-1}", "%{fg:#ff80}", "%{fg:#gg8000}", "%{fg:}"} {
+			t.Run(format, func(t *testing.T) {
+				err := ValidateTime(format)
+				require.ErrorIs(t, err, ErrUnknownColor)
+				require.Equal(t, format, FormatTime(s.Time, format))
+			})
+		}
+		require.ErrorIs(t, ValidateTime("%{bolder}"), ErrUnknownNamespace)
+	})
+
+	require.Contains(t, ColorNames(), "cyan")
+	require.Contains(t, ColorNames(), "bright-white")
+}
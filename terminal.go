@@ -0,0 +1,72 @@
+package logtimer
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// ciVariables are environment variables that are set by CI systems.
+var ciVariables = []string{
+	"CI",
+	"BUILDKITE",
+	"CIRCLECI",
+	"DRONE",
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"JENKINS_URL",
+	"TEAMCITY_VERSION",
+	"TF_BUILD",
+	"TRAVIS",
+}
+
+// IsTerminal reports whether w is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// SupportsStyles reports whether the styling directives should be used for the output w, which is the case if w
+// is a terminal, TERM is not dumb and NO_COLOR is not set. See Compiler.NoStyles.
+func SupportsStyles(w io.Writer) bool {
+	return supportsStyles(IsTerminal(w), os.Getenv)
+}
+
+func supportsStyles(terminal bool, getenv func(string) string) bool {
+	return terminal && getenv("TERM") != "dumb" && getenv("NO_COLOR") == ""
+}
+
+// DetectColorCorrection returns the ColorCorrection that suits the output w:
+//
+//	Disabled   if TERM is dumb or NO_COLOR is set
+//	Tracking   if w is not a terminal or a CI system is detected (CI, GITHUB_ACTIONS, GITLAB_CI, ...)
+//	Alternate  if the terminal is the macOS Terminal, which does not support \x1b[s
+//	Enabled    otherwise
+func DetectColorCorrection(w io.Writer) ColorCorrection {
+	return detectColorCorrection(IsTerminal(w), os.Getenv)
+}
+
+func detectColorCorrection(terminal bool, getenv func(string) string) ColorCorrection {
+	if getenv("TERM") == "dumb" || getenv("NO_COLOR") != "" {
+		return Disabled
+	}
+	if !terminal {
+		return Tracking
+	}
+	for _, key := range ciVariables {
+		if v := getenv(key); v != "" && !strings.EqualFold(v, "false") {
+			return Tracking
+		}
+	}
+	if getenv("TERM_PROGRAM") == "Apple_Terminal" {
+		return Alternate
+	}
+	return Enabled
+}
@@ -0,0 +1,76 @@
+package logtimer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTerminal(t *testing.T) {
+	require.False(t, IsTerminal(&bytes.Buffer{}))
+
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	require.NoError(t, err)
+	defer f.Close()
+	require.False(t, IsTerminal(f))
+}
+
+func TestSupportsStyles(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		env      map[string]string
+		expected bool
+	}{
+		{"Terminal", true, map[string]string{"TERM": "xterm-256color"}, true},
+		{"Pipe", false, map[string]string{"TERM": "xterm-256color"}, false},
+		{"Dumb Terminal", true, map[string]string{"TERM": "dumb"}, false},
+		{"NO_COLOR", true, map[string]string{"TERM": "xterm", "NO_COLOR": "1"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return test.env[key]
+			}
+			require.Equal(t, test.expected, supportsStyles(test.terminal, getenv))
+		})
+	}
+	require.False(t, SupportsStyles(&bytes.Buffer{}))
+}
+
+func TestDetectColorCorrection(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		env      map[string]string
+		expected ColorCorrection
+	}{
+		{"Terminal", true, map[string]string{"TERM": "xterm-256color"}, Enabled},
+		{"Pipe", false, map[string]string{"TERM": "xterm-256color"}, Tracking},
+		{"Dumb Terminal", true, map[string]string{"TERM": "dumb"}, Disabled},
+		{"NO_COLOR", true, map[string]string{"TERM": "xterm", "NO_COLOR": "1"}, Disabled},
+		{"NO_COLOR Pipe", false, map[string]string{"NO_COLOR": "1"}, Disabled},
+		{"CI", true, map[string]string{"TERM": "xterm", "CI": "true"}, Tracking},
+		{"CI false", true, map[string]string{"TERM": "xterm", "CI": "false"}, Enabled},
+		{"GitHub Actions", true, map[string]string{"GITHUB_ACTIONS": "true"}, Tracking},
+		{"Apple Terminal", true, map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "Apple_Terminal"}, Alternate},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return test.env[key]
+			}
+			require.Equal(t, test.expected, detectColorCorrection(test.terminal, getenv))
+		})
+	}
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "")
+		t.Setenv("TERM", "xterm")
+		require.Equal(t, Tracking, DetectColorCorrection(&bytes.Buffer{}))
+		t.Setenv("NO_COLOR", "1")
+		require.Equal(t, Disabled, DetectColorCorrection(&bytes.Buffer{}))
+	})
+}
@@ -0,0 +1,89 @@
+package logtimer
+
+import (
+	"sync"
+	"time"
+)
+
+// Stamp holds the points in time a line is formatted for.
+type Stamp struct {
+	// Time is the time the line started.
+	Time time.Time
+	// Start is the time the Timer was started.
+	Start time.Time
+	// Previous is the time the previous line started, for the first line it is equal to Start.
+	Previous time.Time
+	// Seq is the number of the line, the first line stamped by a Timer is 1.
+	Seq int
+}
+
+// Elapsed returns the time elapsed since the start.
+func (s Stamp) Elapsed() time.Duration {
+	return s.Time.Sub(s.Start)
+}
+
+// Delta returns the time elapsed since the previous line started.
+func (s Stamp) Delta() time.Duration {
+	return s.Time.Sub(s.Previous)
+}
+
+// Timer keeps track of the start time and the start of the previous line.
+// It is safe to use a Timer from multiple goroutines, e.g. to share it between the stdout and stderr of a command.
+type Timer struct {
+	mu       sync.Mutex
+	clock    Clock
+	start    time.Time
+	previous time.Time
+	seq      int
+}
+
+// NewTimer returns a Timer that starts now.
+func NewTimer() *Timer {
+	return NewTimerWithClock(SystemClock)
+}
+
+// NewTimerWithClock returns a Timer that starts at the current time of clock and uses it for Next.
+func NewTimerWithClock(clock Clock) *Timer {
+	clock = clockOrDefault(clock)
+	now := clock.Now()
+	return &Timer{
+		clock:    clock,
+		start:    now,
+		previous: now,
+	}
+}
+
+// Start returns the time the Timer was started.
+func (t *Timer) Start() time.Time {
+	return t.start
+}
+
+// Next returns the Stamp for a line that starts now.
+func (t *Timer) Next() Stamp {
+	return t.NextAt(t.clock.Now())
+}
+
+// NextAt returns the Stamp for a line that started at, e.g. the time its first byte arrived.
+// Lines are stamped in order, if at is before the start of the previous line the start of the previous line is used.
+func (t *Timer) NextAt(at time.Time) Stamp {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.Before(t.previous) {
+		at = t.previous
+	}
+	t.seq++
+	s := Stamp{
+		Time:     at,
+		Start:    t.start,
+		Previous: t.previous,
+		Seq:      t.seq,
+	}
+	t.previous = s.Time
+	return s
+}
+
+// FormatFunc returns a FormatFunc that formats the Stamp of every line with FormatStamp.
+func (t *Timer) FormatFunc(f, defaultNamespace string) FormatFunc {
+	c := Compiler{DefaultNamespace: defaultNamespace}
+	return c.mustCompile(f).FormatFunc(t)
+}
@@ -0,0 +1,43 @@
+package logtimer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimer(t *testing.T) {
+	start := time.Date(2019, time.February, 7, 11, 26, 45, 0, time.UTC)
+	timer := NewTimerWithClock(NewFakeClock(start, 10*time.Millisecond))
+	require.Equal(t, start, timer.Start())
+
+	first := timer.Next()
+	require.Equal(t, start, first.Start)
+	require.Equal(t, first.Start, first.Previous)
+	require.Equal(t, 10*time.Millisecond, first.Elapsed())
+
+	second := timer.Next()
+	require.Equal(t, first.Start, second.Start)
+	require.Equal(t, first.Time, second.Previous)
+	require.Equal(t, 10*time.Millisecond, second.Delta())
+	require.Equal(t, second.Elapsed(), first.Elapsed()+second.Delta())
+}
+
+func TestTimerNextAt(t *testing.T) {
+	timer := NewTimer()
+	// a time before the start stamps the start without advancing the timer
+	start := timer.NextAt(time.Time{}).Time
+
+	first := timer.NextAt(start.Add(time.Second))
+	require.Equal(t, start.Add(time.Second), first.Time)
+	require.Equal(t, time.Second, first.Elapsed())
+
+	second := timer.NextAt(start.Add(3 * time.Second))
+	require.Equal(t, 2*time.Second, second.Delta())
+
+	// lines are stamped in order
+	third := timer.NextAt(start.Add(2 * time.Second))
+	require.Equal(t, second.Time, third.Time)
+	require.Zero(t, third.Delta())
+}
@@ -0,0 +1,57 @@
+package logtimer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"", 0},
+		{"[11:29:57] ", 11},
+		{"[Do 07.02.2019] ", 16},
+		{"[févr. März] ", 13},
+		{"é", 1},
+		{"[木曜日] ", 9},
+		{"[２０１９] ", 11},
+		{"[한국어] ", 9},
+		{"🚀 ", 3},
+		{"✔️ ", 2},
+		{"\x1b[36m[11:29:57]\x1b[0m ", 11},
+		{"\x1b]8;;https://example.com\x07link\x1b]8;;\x1b\\ ", 5},
+		{"\x1b7\x1b[1;38;5;208m木\x1b8", 2},
+		{"\x1b[31mred\x1b(B\x1b[m", 3},
+		{"\x1b(0q\x1b(B", 1},
+		{"\t\x00", 0},
+		{"\xff", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require.Equal(t, test.expected, displayWidth(test.input))
+		})
+	}
+}
+
+func TestStripEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"Hello", "Hello"},
+		{"\x1b[31mred\x1b[0m text", "red text"},
+		{"\x1b]8;;https://example.com\x07link\x1b]8;;\x1b\\", "link"},
+		{"\x1b7saved\x1b8", "saved"},
+		{"\x1b[31mred\x1b(B\x1b[m", "red"},
+		{"\x1b$)Ckorean", "korean"},
+		{"unterminated \x1b[3", "unterminated "},
+		{"unterminated \x1b(", "unterminated "},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, string(stripEscapes(nil, []byte(test.input))), "%q", test.input)
+	}
+}